@@ -0,0 +1,139 @@
+// Package archive exports and imports events in a portable format, e.g. to
+// back up an event store or to move events between environments.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+)
+
+// Format of the archived events.
+type Format int
+
+const (
+	// Protobuf archives events as internal.Event messages, each prefixed by its
+	// length encoded as a varint.
+	Protobuf Format = 0
+	// NDJSON archives events as internal.Event messages in JSON, one per line.
+	NDJSON Format = 1
+)
+
+// MaxEventSize is the maximum size of an event message in a Protobuf archive,
+// larger events are rejected, so that a corrupt archive does not exhaust memory.
+const MaxEventSize = 64 << 20
+
+// Writer writes events to an archive.
+type Writer struct {
+	w      *bufio.Writer
+	format Format
+	m      jsonpb.Marshaler
+}
+
+// NewWriter returns a new Writer writing events to w in the given format.
+func NewWriter(w io.Writer, format Format) *Writer {
+	return &Writer{
+		w:      bufio.NewWriter(w),
+		format: format,
+		m:      jsonpb.Marshaler{OrigName: true},
+	}
+}
+
+// Write writes the event to the archive.
+func (w *Writer) Write(e *hippo.Event) error {
+	pb, err := internal.EncodeEvent(e)
+	if err != nil {
+		return err
+	}
+	switch w.format {
+	case Protobuf:
+		data, err := proto.Marshal(pb)
+		if err != nil {
+			return err
+		}
+		if len(data) > MaxEventSize {
+			return hippo.ErrEventTooLarge
+		}
+		if _, err := w.w.Write(proto.EncodeVarint(uint64(len(data)))); err != nil {
+			return err
+		}
+		_, err = w.w.Write(data)
+		return err
+	case NDJSON:
+		if err := w.m.Marshal(w.w, pb); err != nil {
+			return err
+		}
+		return w.w.WriteByte('\n')
+	default:
+		return hippo.ErrFormatNotProvided
+	}
+}
+
+// Flush writes any buffered events to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader reads events from an archive.
+type Reader struct {
+	r      *bufio.Reader
+	format Format
+}
+
+// NewReader returns a new Reader reading events from r in the given format.
+func NewReader(r io.Reader, format Format) *Reader {
+	return &Reader{
+		r:      bufio.NewReader(r),
+		format: format,
+	}
+}
+
+// Read reads the next event from the archive, or returns io.EOF
+// if there are no more events.
+func (r *Reader) Read() (*hippo.Event, error) {
+	var pb internal.Event
+	switch r.format {
+	case Protobuf:
+		n, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return nil, err
+		}
+		if n > MaxEventSize {
+			return nil, hippo.ErrEventTooLarge
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err := proto.Unmarshal(data, &pb); err != nil {
+			return nil, err
+		}
+	case NDJSON:
+		var line []byte
+		for len(line) == 0 {
+			l, err := r.r.ReadBytes('\n')
+			if err == io.EOF && len(bytes.TrimSpace(l)) == 0 {
+				return nil, io.EOF
+			} else if err != nil && err != io.EOF {
+				return nil, err
+			}
+			line = bytes.TrimSpace(l)
+		}
+		if err := jsonpb.Unmarshal(bytes.NewReader(line), &pb); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, hippo.ErrFormatNotProvided
+	}
+	e := &hippo.Event{}
+	if err := internal.DecodeEvent(&pb, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
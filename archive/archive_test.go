@@ -0,0 +1,64 @@
+package archive_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/archive"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/golang/protobuf/proto"
+)
+
+// newEvent returns an event of the aggregate with the version.
+func newEvent(aggregateID string, version int64) *hippo.Event {
+	e := hippo.NewEventProto("user_created", aggregateID, &pb.User{Id: aggregateID, Name: "Ahsoka"})
+	e.Version = version
+	e.Metadata = map[string]string{"eid": "12345"}
+	e.CreateTime = time.Now().UTC()
+	return e
+}
+
+// Ensure events are written and read back in both formats.
+func TestArchive_WriteRead(t *testing.T) {
+	events := []*hippo.Event{newEvent("a", 1), newEvent("a", 2), newEvent("b", 1)}
+
+	for _, format := range []archive.Format{archive.Protobuf, archive.NDJSON} {
+		var buf bytes.Buffer
+		w := archive.NewWriter(&buf, format)
+		for _, e := range events {
+			if err := w.Write(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		r := archive.NewReader(&buf, format)
+		for _, e := range events {
+			other, err := r.Read()
+			if err != nil {
+				t.Fatal(err)
+			} else if !reflect.DeepEqual(e, other) {
+				t.Fatalf("format %d: unexpected copy: %#v", format, other)
+			}
+		}
+		if _, err := r.Read(); err != io.EOF {
+			t.Fatalf("format %d: unexpected error: %v", format, err)
+		}
+	}
+}
+
+// Ensure an event message larger than the maximum size is rejected.
+func TestArchive_ReadTooLarge(t *testing.T) {
+	for _, n := range []uint64{archive.MaxEventSize + 1, 1 << 63} {
+		r := archive.NewReader(bytes.NewReader(proto.EncodeVarint(n)), archive.Protobuf)
+		if _, err := r.Read(); err != hippo.ErrEventTooLarge {
+			t.Fatalf("size %d: unexpected error: %v", n, err)
+		}
+	}
+}
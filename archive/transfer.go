@@ -0,0 +1,153 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+	"github.com/golang/protobuf/proto"
+)
+
+// ExportOptions is a configurable object for export func
+type ExportOptions struct {
+	// AggregateIDs (optional) exports the events of the aggregates only. All events
+	// are exported in the order they were created if not set, in which case the
+	// event service must implement hippo.StreamService.
+	AggregateIDs []string
+	// After (optional) only events created after this time are exported,
+	// when exporting all events.
+	After time.Time
+	// BatchSize (optional) number of events read at once, defaults to 1000.
+	BatchSize int
+}
+
+// Export writes the events of the event service to the archive and returns
+// the number of events exported.
+func Export(ctx context.Context, es hippo.EventService, w *Writer, opts ExportOptions) (int, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	n := 0
+
+	if len(opts.AggregateIDs) > 0 {
+		for _, id := range opts.AggregateIDs {
+			events, err := es.List(ctx, hippo.Params{ID: id})
+			if err != nil {
+				return n, err
+			}
+			for _, e := range events {
+				if err := w.Write(e); err != nil {
+					return n, err
+				}
+				n++
+			}
+		}
+		return n, w.Flush()
+	}
+
+	st, ok := es.(hippo.StreamService)
+	if !ok {
+		return n, hippo.ErrStreamServiceNotConfigured
+	}
+	// The cursor reads every event once, including events sharing a create
+	// time across batches.
+	var from hippo.Position
+	if !opts.After.IsZero() {
+		from.Time = opts.After.Add(time.Nanosecond)
+	}
+	cursor := hippo.NewCursor(st, from, opts.BatchSize)
+	for {
+		events, err := cursor.Next(ctx)
+		if err != nil {
+			return n, err
+		}
+		if len(events) == 0 {
+			return n, w.Flush()
+		}
+		for _, e := range events {
+			if err := w.Write(e); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+}
+
+// Import creates the events read from the archive in the event service, keeping
+// their versions, timestamps and metadata, and returns the number of events created.
+// The versions of each aggregate must be continuous from the last version in the
+// event service, otherwise the import stops with hippo.ErrVersionNotContinuous.
+// Events already in the event service are skipped, so an interrupted import is
+// resumed by importing the same archive again. Skipped events must match the
+// events stored, otherwise the import stops with hippo.ErrEventMismatch.
+func Import(ctx context.Context, es hippo.EventService, r *Reader) (int, error) {
+	// last version of the aggregates
+	versions := make(map[string]int64)
+	n, skipped := 0, 0
+	for {
+		e, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return n, err
+		}
+
+		v, ok := versions[e.AggregateID]
+		if !ok {
+			v, err = es.GetLastVersion(ctx, e.AggregateID)
+			if err != nil {
+				return n, err
+			}
+		}
+		switch {
+		case e.Version <= v:
+			versions[e.AggregateID] = v
+			if err := match(ctx, es, e); err != nil {
+				return n, err
+			}
+			skipped++
+			continue
+		case e.Version != v+1:
+			return n, hippo.ErrVersionNotContinuous
+		}
+
+		if err := es.Create(ctx, e); err != nil {
+			return n, err
+		}
+		versions[e.AggregateID] = e.Version
+		n++
+	}
+	if skipped > 0 {
+		log.Printf("archive: %d events already imported were skipped", skipped)
+	}
+	return n, nil
+}
+
+// match returns hippo.ErrEventMismatch unless the event stored with the version
+// of the event is the same event.
+func match(ctx context.Context, es hippo.EventService, e *hippo.Event) error {
+	events, err := es.List(ctx, hippo.Params{ID: e.AggregateID, FromVersion: e.Version, ToVersion: e.Version})
+	if err != nil {
+		return err
+	}
+	if len(events) != 1 {
+		log.Printf("archive: event %s with aggregate %s version %d not found", e.Topic, e.AggregateID, e.Version)
+		return hippo.ErrEventMismatch
+	}
+	a, err := internal.EncodeEvent(e)
+	if err != nil {
+		return err
+	}
+	b, err := internal.EncodeEvent(events[0])
+	if err != nil {
+		return err
+	}
+	if !proto.Equal(a, b) {
+		log.Printf("archive: event %s with aggregate %s version %d differs from the event stored", e.Topic, e.AggregateID, e.Version)
+		return hippo.ErrEventMismatch
+	}
+	return nil
+}
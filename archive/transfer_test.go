@@ -0,0 +1,82 @@
+package archive_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/archive"
+	"github.com/aukbit/hippo/mock"
+	"github.com/paulormart/assert"
+)
+
+// MustOpenEventService returns a mock event service holding the events in memory.
+func MustOpenEventService(events ...*hippo.Event) (*mock.EventService, *mock.Events) {
+	store := mock.NewEvents(events...)
+	return store.EventService(), store
+}
+
+// Ensure all events are exported and imported into another event service.
+func TestExportImport(t *testing.T) {
+	first := newEvent("a", 1)
+	// Events sharing a create time across a batch boundary.
+	b1, a2 := newEvent("b", 1), newEvent("a", 2)
+	a2.CreateTime = b1.CreateTime
+	src, _ := MustOpenEventService(first, b1, a2)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	n, err := archive.Export(ctx, src, archive.NewWriter(&buf, archive.NDJSON), archive.ExportOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, n)
+
+	// Destination already holds the first event, as if an import was interrupted.
+	dst, events := MustOpenEventService(first)
+	n, err = archive.Import(ctx, dst, archive.NewReader(bytes.NewReader(buf.Bytes()), archive.NDJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 3, len(events.All()))
+
+	// Importing again is a no-op.
+	n, err = archive.Import(ctx, dst, archive.NewReader(bytes.NewReader(buf.Bytes()), archive.NDJSON))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, n)
+
+	// Export the events of an aggregate.
+	buf.Reset()
+	n, err = archive.Export(ctx, src, archive.NewWriter(&buf, archive.Protobuf), archive.ExportOptions{AggregateIDs: []string{"a"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, n)
+}
+
+// Ensure import stops when an event already stored differs from the archived one.
+func TestImport_EventMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	w := archive.NewWriter(&buf, archive.Protobuf)
+	w.Write(newEvent("a", 1))
+	w.Flush()
+
+	dst, _ := MustOpenEventService(newEvent("a", 1))
+	n, err := archive.Import(context.Background(), dst, archive.NewReader(&buf, archive.Protobuf))
+	assert.Equal(t, hippo.ErrEventMismatch, err)
+	assert.Equal(t, 0, n)
+}
+
+// Ensure import stops when versions are not continuous.
+func TestImport_VersionNotContinuous(t *testing.T) {
+	var buf bytes.Buffer
+	w := archive.NewWriter(&buf, archive.Protobuf)
+	w.Write(newEvent("a", 1))
+	w.Write(newEvent("a", 3))
+	w.Flush()
+
+	dst, _ := MustOpenEventService()
+	n, err := archive.Import(context.Background(), dst, archive.NewReader(&buf, archive.Protobuf))
+	assert.Equal(t, hippo.ErrVersionNotContinuous, err)
+	assert.Equal(t, 1, n)
+}
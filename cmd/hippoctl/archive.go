@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aukbit/hippo/archive"
+)
+
+// archiveFormat returns the archive format of the name.
+func archiveFormat(name string) (archive.Format, error) {
+	switch name {
+	case "ndjson":
+		return archive.NDJSON, nil
+	case "protobuf":
+		return archive.Protobuf, nil
+	default:
+		return 0, fmt.Errorf("unknown format %q", name)
+	}
+}
+
+// export exports the events of the aggregates, or all events, to a file or stdout.
+func (m *Main) export(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("hippoctl export", flag.ContinueOnError)
+	fs.SetOutput(m.Stderr)
+	name := fs.String("format", "ndjson", "archive format: ndjson or protobuf")
+	output := fs.String("o", "", "output file, defaults to stdout")
+	after := fs.String("after", "", "only events created after this RFC3339 time, when exporting all events")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	format, err := archiveFormat(*name)
+	if err != nil {
+		return err
+	}
+	opts := archive.ExportOptions{AggregateIDs: fs.Args()}
+	if *after != "" {
+		t, err := time.Parse(time.RFC3339Nano, *after)
+		if err != nil {
+			return fmt.Errorf("invalid after time %q", *after)
+		}
+		opts.After = t
+	}
+
+	var w io.Writer = m.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	n, err := archive.Export(ctx, m.Store.EventService(), archive.NewWriter(w, format), opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(m.Stderr, "%d events exported\n", n)
+	return nil
+}
+
+// importEvents imports the events exported to a file, or read from stdin.
+func (m *Main) importEvents(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("hippoctl import", flag.ContinueOnError)
+	fs.SetOutput(m.Stderr)
+	name := fs.String("format", "ndjson", "archive format: ndjson or protobuf")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: hippoctl import [flags] [file]")
+	}
+	format, err := archiveFormat(*name)
+	if err != nil {
+		return err
+	}
+
+	r := m.Stdin
+	if fs.NArg() == 1 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	n, err := archive.Import(ctx, m.Store.EventService(), archive.NewReader(r, format))
+	fmt.Fprintf(m.Stderr, "%d events imported\n", n)
+	return err
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/grpc"
+	"github.com/aukbit/hippo/internal"
+)
+
+// aggregates lists the aggregates with events, reading all events from the
+// event store in the order they were created.
+func (m *Main) aggregates(ctx context.Context) error {
+	st, ok := m.Store.EventService().(hippo.StreamService)
+	if !ok {
+		return hippo.ErrStreamServiceNotConfigured
+	}
+
+	type aggregate struct {
+		events  int
+		version int64
+		last    time.Time
+	}
+	aggregates := make(map[string]*aggregate)
+	// The cursor reads every event once, including events sharing a create time.
+	cursor := hippo.NewCursor(st, hippo.Position{}, 1000)
+	for {
+		events, err := cursor.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			break
+		}
+		for _, e := range events {
+			a, ok := aggregates[e.AggregateID]
+			if !ok {
+				a = &aggregate{}
+				aggregates[e.AggregateID] = a
+			}
+			a.events++
+			if e.Version > a.version {
+				a.version = e.Version
+			}
+			a.last = e.CreateTime
+		}
+	}
+
+	ids := make([]string, 0, len(aggregates))
+	for id := range aggregates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	w := tabwriter.NewWriter(m.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "AGGREGATE\tEVENTS\tVERSION\tLAST EVENT")
+	for _, id := range ids {
+		a := aggregates[id]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", id, a.events, a.version, a.last.Format(time.RFC3339Nano))
+	}
+	return w.Flush()
+}
+
+// events lists the events of an aggregate, optionally between versions.
+func (m *Main) events(ctx context.Context, args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return fmt.Errorf("usage: hippoctl events <id> [from] [to]")
+	}
+	p := hippo.Params{ID: args[0]}
+	if len(args) > 1 {
+		v, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid from version %q", args[1])
+		}
+		p.FromVersion = v
+	}
+	if len(args) > 2 {
+		v, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid to version %q", args[2])
+		}
+		p.ToVersion = v
+	}
+
+	events, err := m.Store.EventService().List(ctx, p)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(m.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tTOPIC\tSCHEMA\tFORMAT\tCREATE TIME")
+	for _, e := range events {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", e.Version, e.Topic, e.Schema, format(e.Format), e.CreateTime.Format(time.RFC3339Nano))
+	}
+	return w.Flush()
+}
+
+// event shows an event of an aggregate.
+func (m *Main) event(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: hippoctl event <id> <version>")
+	}
+	v, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid version %q", args[1])
+	}
+	events, err := m.Store.EventService().List(ctx, hippo.Params{ID: args[0], FromVersion: v, ToVersion: v})
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if e.Version == v {
+			return printEvent(m.Stdout, e)
+		}
+	}
+	return hippo.ErrEventNotFound
+}
+
+// version prints the last version of an aggregate.
+func (m *Main) version(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: hippoctl version <id>")
+	}
+	v, err := m.Store.EventService().GetLastVersion(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(m.Stdout, v)
+	return nil
+}
+
+// decode decodes the text encoded events read from stdin, e.g. the data
+// field of the events stored in InfluxDB.
+func (m *Main) decode() error {
+	scanner := bufio.NewScanner(m.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e hippo.Event
+		if err := internal.UnmarshalEventText(line, &e); err != nil {
+			return err
+		}
+		if err := printEvent(m.Stdout, &e); err != nil {
+			return err
+		}
+		fmt.Fprintln(m.Stdout)
+	}
+	return scanner.Err()
+}
+
+// cache evicts or rebuilds an aggregate in cache. Cache keys and states depend on
+// the domain type, so both require the domain type to be registered in domains.
+func (m *Main) cache(ctx context.Context, args []string) error {
+	if len(args) != 2 || (args[0] != "evict" && args[0] != "rebuild") {
+		return fmt.Errorf("usage: hippoctl cache evict|rebuild <id>")
+	}
+	if m.Cache == nil {
+		return hippo.ErrCacheServiceNotConfigured
+	}
+	id := args[1]
+
+	// Domain type of the aggregate from the schema of its last event.
+	events, err := m.Store.EventService().List(ctx, hippo.Params{ID: id})
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return hippo.ErrAggregateIDWithoutEvents
+	}
+	schema := events[len(events)-1].Schema
+	d, ok := domains[schema]
+	if !ok {
+		if len(domains) == 0 {
+			return errNoDomains
+		}
+		return fmt.Errorf("domain type %s not registered in hippoctl", schema)
+	}
+
+	cache, deletes := m.Cache.(hippo.CacheDeleter)
+	if !deletes {
+		return hippo.ErrNotImplemented
+	}
+	if err := cache.Delete(ctx, id, d.buffer); err != nil {
+		return err
+	}
+	if args[0] == "evict" {
+		fmt.Fprintf(m.Stdout, "aggregate %s evicted\n", id)
+		return nil
+	}
+
+	clt := hippo.NewClient(m.Store)
+	clt.RegisterDomainRules(d.rules, d.buffer)
+	clt.RegisterCacheService(m.Cache)
+	agg, err := clt.FetchWithOptions(ctx, id, reflect.New(reflect.TypeOf(d.buffer).Elem()).Interface(), hippo.FetchOptions{SkipCache: true})
+	if err != nil {
+		return err
+	}
+	if err := m.Cache.Set(ctx, id, agg); err != nil {
+		return err
+	}
+	fmt.Fprintf(m.Stdout, "aggregate %s rebuilt at version %d\n", id, agg.Version)
+	return nil
+}
+
+// tail prints new events of the topic until the context is done. Events are
+// streamed from a remote store, or polled from stores implementing hippo.StreamService
+// starting from the events created in the since window, so that events of hosts
+// with clocks behind are not missed.
+func (m *Main) tail(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("hippoctl tail", flag.ContinueOnError)
+	fs.SetOutput(m.Stderr)
+	since := fs.Duration("since", 10*time.Second, "print the events created since, e.g. 1m")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: hippoctl tail [-since duration] [topic]")
+	}
+	topic := "*"
+	if fs.NArg() == 1 {
+		topic = fs.Arg(0)
+	}
+
+	show := func(ctx context.Context, e *hippo.Event) error {
+		fmt.Fprintf(m.Stdout, "%s\t%s\t%d\t%s\n", e.CreateTime.Format(time.RFC3339Nano), e.AggregateID, e.Version, e.Topic)
+		return nil
+	}
+
+	if s, ok := m.Store.(*grpc.StoreService); ok {
+		return s.Subscribe(ctx, grpc.SubscribeParams{After: time.Now().Add(-*since)}, hippo.ActionTopics{hippo.Topic(topic): []hippo.ActionFn{show}})
+	}
+
+	st, ok := m.Store.EventService().(hippo.StreamService)
+	if !ok {
+		return hippo.ErrStreamServiceNotConfigured
+	}
+	// The cursor reads every event once, including events sharing a create time.
+	cursor := hippo.NewCursor(st, hippo.Position{Time: time.Now().Add(-*since)}, 100)
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		events, err := cursor.Next(ctx)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if ok, _ := path.Match(topic, e.Topic); ok {
+				show(ctx, e)
+			}
+		}
+		if len(events) > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// verify verifies the signature chain of the events of an aggregate.
+func (m *Main) verify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("hippoctl verify", flag.ContinueOnError)
+	fs.SetOutput(m.Stderr)
+	key := fs.String("key", os.Getenv("HIPPO_SIGNATURE_KEY"), "HMAC key the events were signed with, defaults to $HIPPO_SIGNATURE_KEY")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: hippoctl verify [flags] <id>")
+	}
+
+	// Anyone can recompute a chain signed without a key.
+	if *key == "" {
+		fmt.Fprintln(m.Stderr, "warning: no key set, the events are verified with unkeyed SHA-256 signatures anyone can recompute")
+	}
+
+	events, err := m.Store.EventService().List(ctx, hippo.Params{ID: fs.Arg(0)})
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return hippo.ErrAggregateIDWithoutEvents
+	}
+
+	// The heads of the chains are stored in the cache, e.g. Redis.
+	var head hippo.Head
+	if c, ok := m.Cache.(interface{ HeadService() hippo.HeadService }); ok {
+		if head, err = c.HeadService().GetHead(ctx, fs.Arg(0)); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(m.Stderr, "warning: no cache with the heads of the chains set, deleted last events are not detected")
+	}
+	if err := hippo.NewSigner([]byte(*key)).VerifyHead(events, head); err != nil {
+		return err
+	}
+	fmt.Fprintf(m.Stdout, "aggregate %s verified, %d events\n", fs.Arg(0), len(events))
+	return nil
+}
+
+// printEvent prints the fields of the event, data is printed as text for the
+// json and string formats and base64 encoded otherwise.
+func printEvent(w io.Writer, e *hippo.Event) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "topic:\t%s\n", e.Topic)
+	fmt.Fprintf(tw, "aggregate_id:\t%s\n", e.AggregateID)
+	fmt.Fprintf(tw, "version:\t%d\n", e.Version)
+	fmt.Fprintf(tw, "schema:\t%s\n", e.Schema)
+	fmt.Fprintf(tw, "format:\t%s\n", format(e.Format))
+	fmt.Fprintf(tw, "priority:\t%d\n", e.Priority)
+	fmt.Fprintf(tw, "signature:\t%s\n", e.Signature)
+	fmt.Fprintf(tw, "origin_name:\t%s\n", e.OriginName)
+	fmt.Fprintf(tw, "origin_ip:\t%s\n", e.OriginIP)
+	keys := make([]string, 0, len(e.Metadata))
+	for k := range e.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(tw, "metadata.%s:\t%s\n", k, e.Metadata[k])
+	}
+	fmt.Fprintf(tw, "create_time:\t%s\n", e.CreateTime.Format(time.RFC3339Nano))
+	switch e.Format {
+	case hippo.JSON, hippo.STRING:
+		fmt.Fprintf(tw, "data:\t%s\n", e.Data)
+	default:
+		fmt.Fprintf(tw, "data:\t%s\n", base64.StdEncoding.EncodeToString(e.Data))
+	}
+	return tw.Flush()
+}
+
+// format returns the name of the event format.
+func format(f hippo.Format) string {
+	switch f {
+	case hippo.PROTOBUF:
+		return "protobuf"
+	case hippo.JSON:
+		return "json"
+	case hippo.STRING:
+		return "string"
+	default:
+		return strconv.Itoa(int(f))
+	}
+}
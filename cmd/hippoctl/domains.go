@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/aukbit/hippo"
+)
+
+// domain represents a domain type whose aggregates can be rebuilt in cache.
+type domain struct {
+	// buffer is the domain type, e.g. &pb.User{}
+	buffer interface{}
+	rules  hippo.DomainTypeRulesFn
+}
+
+// domains holds the domain types known to hippoctl by schema. Aggregates are
+// rebuilt in cache from their events with the domain rules, which only the
+// services know, so build hippoctl for a service by registering its domain
+// types here, e.g.
+//
+//	"*user.User": {buffer: &pb.User{}, rules: userRules},
+var domains = map[string]domain{}
+
+// errNoDomains is returned by the cache commands of a hippoctl built without domain types.
+var errNoDomains = errors.New("no domain types registered in hippoctl, build it with the domain types of the service, see cmd/hippoctl/domains.go")
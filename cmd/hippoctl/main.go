@@ -0,0 +1,186 @@
+// Command hippoctl inspects and operates hippo event stores and caches.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/grpc"
+	"github.com/aukbit/hippo/influxdb"
+	"github.com/aukbit/hippo/redis"
+)
+
+const usage = `hippoctl inspects and operates hippo event stores and caches.
+
+Usage:
+
+	hippoctl [flags] <command> [arguments]
+
+Commands:
+
+	aggregates                  list the aggregates with events
+	events <id> [from] [to]     list the events of an aggregate, optionally between versions
+	event <id> <version>        show an event of an aggregate
+	version <id>                print the last version of an aggregate
+	decode                      decode text encoded events read from stdin, one per line
+	cache evict <id>            evict an aggregate from cache, requires its domain type
+	cache rebuild <id>          rebuild an aggregate in cache from its events, requires its domain type
+	tail [flags] [topic]        print new events, topic patterns are supported
+	export [flags] [id...]      export the events of the aggregates, or all events
+	import [flags] [file]       import events exported to a file, or read from stdin
+	migrate [flags]             copy all events to another store and verify them
+	verify [flags] <id>         verify the signature chain of the events of an aggregate
+
+Flags:
+`
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Stop on interrupt, e.g. while tailing events.
+	sigch := make(chan os.Signal, 1)
+	signal.Notify(sigch, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-sigch
+		cancel()
+	}()
+
+	m := NewMain()
+	if err := m.Run(ctx, os.Args[1:]...); err == flag.ErrHelp {
+		os.Exit(2)
+	} else if err != nil {
+		fmt.Fprintln(m.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Main represents the program.
+type Main struct {
+	// Store and optional cache the commands run against,
+	// connected from the flags if not set.
+	Store hippo.StoreService
+	Cache hippo.CacheService
+
+	// Destination store of the migration, connected from the flags if not set.
+	Destination hippo.StoreService
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Interval between polls of the event store while tailing events.
+	Interval time.Duration
+}
+
+// NewMain returns a new instance of Main.
+func NewMain() *Main {
+	return &Main{
+		Stdin:    os.Stdin,
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+		Interval: time.Second,
+	}
+}
+
+// Run parses the flags, connects to the store and cache and runs the command.
+func (m *Main) Run(ctx context.Context, args ...string) error {
+	fs := flag.NewFlagSet("hippoctl", flag.ContinueOnError)
+	fs.SetOutput(m.Stderr)
+	store := fs.String("store", "influxdb", "event store backend: influxdb or grpc")
+	addr := fs.String("addr", "", "event store address, defaults to the backend default")
+	database := fs.String("database", "", "influxdb database, defaults to hippo_db")
+	cache := fs.String("cache", "", "cache backend: redis, optional")
+	cacheAddr := fs.String("cache-addr", "", "redis address, defaults to localhost:6379")
+	cachePrefix := fs.String("cache-prefix", "", "redis key prefix, optional")
+	cacheSchemaKeys := fs.Bool("cache-schema-keys", false, "redis keys include the state schema")
+	fs.Usage = func() {
+		fmt.Fprint(m.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	if m.Store == nil {
+		s, close, err := connect(*store, *addr, *database)
+		if err != nil {
+			return err
+		}
+		defer close()
+		m.Store = s
+	}
+
+	if m.Cache == nil && *cache != "" {
+		switch *cache {
+		case "redis":
+			c := redis.NewCacheService()
+			if err := c.Link(redis.Config{Addr: *cacheAddr, Prefix: *cachePrefix, SchemaKeys: *cacheSchemaKeys}); err != nil {
+				return err
+			}
+			defer c.Close()
+			m.Cache = c
+		default:
+			return fmt.Errorf("unknown cache backend %q", *cache)
+		}
+	}
+
+	cmd, args := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "aggregates":
+		return m.aggregates(ctx)
+	case "events":
+		return m.events(ctx, args)
+	case "event":
+		return m.event(ctx, args)
+	case "version":
+		return m.version(ctx, args)
+	case "decode":
+		return m.decode()
+	case "cache":
+		return m.cache(ctx, args)
+	case "tail":
+		return m.tail(ctx, args)
+	case "export":
+		return m.export(ctx, args)
+	case "import":
+		return m.importEvents(ctx, args)
+	case "migrate":
+		return m.migrate(ctx, args)
+	case "verify":
+		return m.verify(ctx, args)
+	default:
+		return fmt.Errorf("unknown command %q, run hippoctl -h for usage", cmd)
+	}
+}
+
+// connect connects to the event store backend.
+func connect(backend, addr, database string) (hippo.StoreService, func() error, error) {
+	switch backend {
+	case "influxdb":
+		s := influxdb.NewStoreService()
+		if err := s.Connect(influxdb.Config{Addr: addr, Database: database}); err != nil {
+			return nil, nil, err
+		}
+		return s, s.Close, nil
+	case "grpc":
+		s := grpc.NewStoreService()
+		if err := s.Connect(grpc.Config{Addr: addr}); err != nil {
+			return nil, nil, err
+		}
+		return s, s.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown store backend %q", backend)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+	"github.com/aukbit/hippo/memory"
+	"github.com/aukbit/hippo/mock"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/paulormart/assert"
+)
+
+// MustOpenMain returns a Main running against a mock store with the events
+// of an aggregate and an in-memory cache.
+func MustOpenMain(events ...*hippo.Event) (*Main, *bytes.Buffer) {
+	var stdout bytes.Buffer
+	m := NewMain()
+	m.Store = mock.NewEvents(events...).StoreService()
+	m.Cache = memory.NewCacheService(memory.Config{})
+	m.Stdout = &stdout
+	return m, &stdout
+}
+
+// newEvent returns a user event with the version.
+func newEvent(topic, name string, version int64) *hippo.Event {
+	e := hippo.NewEventProto(topic, "luke", &pb.User{Id: "luke", Name: name})
+	e.Version = version
+	return e
+}
+
+func TestMain_Events(t *testing.T) {
+	m, stdout := MustOpenMain(newEvent("user_created", "Luke", 1), newEvent("user_renamed", "Skywalker", 2))
+	ctx := context.Background()
+
+	if err := m.Run(ctx, "aggregates"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, true, strings.Contains(stdout.String(), "luke       2       2"))
+
+	stdout.Reset()
+	if err := m.Run(ctx, "events", "luke", "2", "2"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, strings.Count(stdout.String(), "\n"))
+	assert.Equal(t, true, strings.Contains(stdout.String(), "user_renamed"))
+
+	stdout.Reset()
+	if err := m.Run(ctx, "events", "luke", "2"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, strings.Count(stdout.String(), "\n"))
+	assert.Equal(t, false, strings.Contains(stdout.String(), "user_created"))
+
+	stdout.Reset()
+	if err := m.Run(ctx, "event", "luke", "1"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, true, strings.Contains(stdout.String(), "topic:        user_created"))
+
+	assert.Equal(t, hippo.ErrEventNotFound, m.Run(ctx, "event", "luke", "3"))
+
+	stdout.Reset()
+	if err := m.Run(ctx, "version", "luke"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "2\n", stdout.String())
+}
+
+func TestMain_Decode(t *testing.T) {
+	m, stdout := MustOpenMain()
+	text, err := internal.MarshalEventText(newEvent("user_created", "Luke", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Stdin = strings.NewReader(text + "\n")
+
+	if err := m.Run(context.Background(), "decode"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, true, strings.Contains(stdout.String(), "aggregate_id: luke"))
+	assert.Equal(t, true, strings.Contains(stdout.String(), "version:      1"))
+}
+
+func TestMain_Cache(t *testing.T) {
+	m, stdout := MustOpenMain(newEvent("user_created", "Luke", 1), newEvent("user_renamed", "Skywalker", 2))
+	ctx := context.Background()
+
+	// Unknown domain types are neither evicted nor rebuilt.
+	assert.Equal(t, errNoDomains, m.Run(ctx, "cache", "evict", "luke"))
+
+	// Domain type of the aggregate.
+	domains["*user.User"] = domain{
+		buffer: &pb.User{},
+		rules: func(topic string, buffer, previous interface{}) (next interface{}) {
+			return buffer
+		},
+	}
+	defer delete(domains, "*user.User")
+
+	if err := m.Run(ctx, "cache", "rebuild", "luke"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "aggregate luke rebuilt at version 2\n", stdout.String())
+
+	agg := &hippo.Aggregate{State: &pb.User{}}
+	if err := m.Cache.Get(ctx, "luke", agg); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Skywalker", agg.State.(*pb.User).GetName())
+
+	if err := m.Run(ctx, "cache", "evict", "luke"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hippo.ErrKeyDoesNotExist, m.Cache.Get(ctx, "luke", &hippo.Aggregate{State: &pb.User{}}))
+}
+
+func TestMain_Tail(t *testing.T) {
+	m, stdout := MustOpenMain(newEvent("user_created", "Luke", 1), newEvent("user_renamed", "Skywalker", 2))
+	m.Interval = time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Every event is printed once, even if the store returns it again.
+	if err := m.Run(ctx, "tail", "user_*"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, strings.Count(stdout.String(), "\tluke\t"))
+}
+
+func TestMain_Export(t *testing.T) {
+	m, stdout := MustOpenMain(newEvent("user_created", "Luke", 1), newEvent("user_renamed", "Skywalker", 2))
+	m.Stderr = &bytes.Buffer{}
+
+	if err := m.Run(context.Background(), "export", "-format", "ndjson", "luke"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, strings.Count(stdout.String(), "\n"))
+	assert.Equal(t, true, strings.Contains(stdout.String(), `"topic":"user_renamed"`))
+	assert.Equal(t, "2 events exported\n", m.Stderr.(*bytes.Buffer).String())
+}
+
+func TestMain_Verify(t *testing.T) {
+	signer := hippo.NewSigner([]byte("secret"))
+	ev1, ev2 := newEvent("user_created", "Luke", 1), newEvent("user_renamed", "Skywalker", 2)
+	ev1.Signature = signer.Sign(ev1, "")
+	ev2.Signature = signer.Sign(ev2, ev1.Signature)
+	m, stdout := MustOpenMain(ev1, ev2)
+	ctx := context.Background()
+
+	if err := m.Run(ctx, "verify", "-key", "secret", "luke"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "aggregate luke verified, 2 events\n", stdout.String())
+
+	err := m.Run(ctx, "verify", "-key", "other", "luke")
+	assert.Equal(t, "aggregate luke version 1: invalid event signature", err.Error())
+
+	// Verifying without a key warns that the chain is not keyed.
+	var stderr bytes.Buffer
+	m.Stderr = &stderr
+	m.Run(ctx, "verify", "-key", "", "luke")
+	assert.Equal(t, true, strings.Contains(stderr.String(), "warning: no key set"))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/migrate"
+)
+
+// migrate copies all events to another store, verifies the copied aggregates
+// and optionally follows new events until interrupted.
+func (m *Main) migrate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("hippoctl migrate", flag.ContinueOnError)
+	fs.SetOutput(m.Stderr)
+	store := fs.String("to-store", "grpc", "destination event store backend: influxdb or grpc")
+	addr := fs.String("to-addr", "", "destination event store address, defaults to the backend default")
+	database := fs.String("to-database", "", "destination influxdb database, defaults to hippo_db")
+	checkpoint := fs.String("checkpoint", "", "file where the position is saved to resume the migration, optional")
+	follow := fs.Bool("follow", false, "keep copying new events until interrupted")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if m.Destination == nil {
+		s, close, err := connect(*store, *addr, *database)
+		if err != nil {
+			return err
+		}
+		defer close()
+		m.Destination = s
+	}
+
+	conf := migrate.Config{
+		Name: "hippoctl",
+		Progress: func(p migrate.Progress) {
+			fmt.Fprintf(m.Stderr, "%d events copied, %d skipped, position %s\n", p.Copied, p.Skipped, p.Position.Format(time.RFC3339Nano))
+		},
+	}
+	if *checkpoint != "" {
+		conf.Checkpoints = &fileCheckpoints{path: *checkpoint}
+	}
+	mg := migrate.NewMigrator(m.Store, m.Destination, conf)
+
+	if _, err := mg.Copy(ctx); err != nil {
+		return err
+	}
+	mismatches, err := mg.Verify(ctx, mg.Aggregates()...)
+	if err != nil {
+		return err
+	}
+	for _, mm := range mismatches {
+		fmt.Fprintf(m.Stdout, "aggregate %s differs: %d source events %s, %d destination events %s\n",
+			mm.AggregateID, mm.SourceEvents, mm.SourceChecksum, mm.DestinationEvents, mm.DestinationChecksum)
+	}
+	fmt.Fprintf(m.Stdout, "%d aggregates verified, %d differ\n", len(mg.Aggregates()), len(mismatches))
+	if len(mismatches) > 0 {
+		return fmt.Errorf("migration verification failed")
+	}
+
+	if *follow {
+		return mg.Follow(ctx)
+	}
+	return nil
+}
+
+var _ hippo.CheckpointService = &fileCheckpoints{}
+
+// fileCheckpoints is a hippo.CheckpointService saving a single position to a file.
+type fileCheckpoints struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Get returns the position saved in the file, or the zero position if there is no file.
+func (s *fileCheckpoints) Get(ctx context.Context, name string) (hippo.Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return hippo.Position{}, nil
+	} else if err != nil {
+		return hippo.Position{}, err
+	}
+	// The time is on the first line followed by the keys of the events read with that time.
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if lines[0] == "" {
+		return hippo.Position{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, lines[0])
+	if err != nil {
+		return hippo.Position{}, err
+	}
+	return hippo.Position{Time: t, Keys: lines[1:]}, nil
+}
+
+// Set saves the position to the file.
+func (s *fileCheckpoints) Set(ctx context.Context, name string, position hippo.Position) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines := append([]string{position.Time.Format(time.RFC3339Nano)}, position.Keys...)
+	return ioutil.WriteFile(s.path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
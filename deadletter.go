@@ -0,0 +1,120 @@
+package hippo
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DeadLetter represents an event whose action exhausted all retry attempts.
+type DeadLetter struct {
+	// ID of the dead letter, assigned by the DeadLetterService.
+	ID string
+	// Event that could not be processed.
+	Event *Event
+	// Action that failed to process the event.
+	Action ActionFn
+	// Attempts number of times the action ran.
+	Attempts int
+	// Err is the last error returned by the action.
+	Err error
+	// CreateTime timestamp when the event was dead-lettered.
+	CreateTime time.Time
+}
+
+// DeadLetterService represents a service for managing events that exhausted
+// their retries.
+type DeadLetterService interface {
+	Put(ctx context.Context, d *DeadLetter) error
+	List(ctx context.Context) ([]*DeadLetter, error)
+	Replay(ctx context.Context, id string) error
+	Discard(ctx context.Context, id string) error
+}
+
+// Ensure DeadLetterQueue implements DeadLetterService.
+var _ DeadLetterService = &DeadLetterQueue{}
+
+// DeadLetterQueue is an in-memory DeadLetterService.
+type DeadLetterQueue struct {
+	mu      sync.Mutex
+	seq     int64
+	letters map[string]*DeadLetter
+	order   []string
+}
+
+// NewDeadLetterQueue creates a new DeadLetterQueue
+func NewDeadLetterQueue() *DeadLetterQueue {
+	return &DeadLetterQueue{
+		letters: make(map[string]*DeadLetter),
+	}
+}
+
+// Put adds a dead letter to the queue and assigns it an ID.
+func (q *DeadLetterQueue) Put(ctx context.Context, d *DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	d.ID = strconv.FormatInt(q.seq, 10)
+	if d.CreateTime.IsZero() {
+		d.CreateTime = time.Now().UTC()
+	}
+	q.letters[d.ID] = d
+	q.order = append(q.order, d.ID)
+	return nil
+}
+
+// List returns the dead letters in the order they were queued.
+func (q *DeadLetterQueue) List(ctx context.Context) ([]*DeadLetter, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	letters := make([]*DeadLetter, 0, len(q.order))
+	for _, id := range q.order {
+		letters = append(letters, q.letters[id])
+	}
+	return letters, nil
+}
+
+// Replay runs the failed action once more. On success the dead letter is
+// removed from the queue, otherwise the attempt and error are recorded.
+func (q *DeadLetterQueue) Replay(ctx context.Context, id string) error {
+	q.mu.Lock()
+	d, ok := q.letters[id]
+	q.mu.Unlock()
+	if !ok {
+		return ErrDeadLetterNotFound
+	}
+
+	start := time.Now()
+	if err := d.Action(ctx, d.Event); err != nil {
+		q.mu.Lock()
+		d.Attempts++
+		d.Err = err
+		q.mu.Unlock()
+		log.Printf("pubsub: dead letter %s replay failed for event %s with aggregate %s version %d - duration: %v > error %v", id, d.Event.Topic, d.Event.AggregateID, d.Event.Version, time.Now().Sub(start), err)
+		return err
+	}
+	log.Printf("pubsub: dead letter %s replayed for event %s with aggregate %s version %d - duration: %v", id, d.Event.Topic, d.Event.AggregateID, d.Event.Version, time.Now().Sub(start))
+	return q.Discard(ctx, id)
+}
+
+// Discard removes a dead letter from the queue.
+func (q *DeadLetterQueue) Discard(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.letters[id]; !ok {
+		return ErrDeadLetterNotFound
+	}
+	delete(q.letters, id)
+	for i, v := range q.order {
+		if v == id {
+			q.order = append(q.order[:i], q.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package hippo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// CheckpointService represents a service for managing the position of durable
+// subscriptions in the event stream.
+type CheckpointService interface {
+	// Get returns the position of the subscription, or the zero position if the
+	// subscription has no checkpoint yet.
+	Get(ctx context.Context, name string) (Position, error)
+	Set(ctx context.Context, name string, position Position) error
+}
+
+// Position represents how far a reader of the event stream got: the create time
+// of the last events read and the keys of the events read with that create time,
+// so that events sharing a create time are neither skipped nor read twice.
+type Position struct {
+	// Time is the create time of the last events read.
+	Time time.Time
+	// Keys of the events read with that create time, see EventKey.
+	Keys []string
+}
+
+// EventKey returns the key identifying the event in the event stream.
+func EventKey(e *Event) string {
+	return fmt.Sprintf("%s:%d", e.AggregateID, e.Version)
+}
+
+// IsZero reports whether the position is at the beginning of the event stream.
+func (p Position) IsZero() bool {
+	return p.Time.IsZero() && len(p.Keys) == 0
+}
+
+// Contains reports whether the event was read before the position.
+func (p Position) Contains(e *Event) bool {
+	if e.CreateTime.Before(p.Time) {
+		return true
+	}
+	if !e.CreateTime.Equal(p.Time) {
+		return false
+	}
+	k := EventKey(e)
+	for _, key := range p.Keys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Advance returns the position after the event is read. The position never
+// moves back, e.g. for events written by hosts with a clock behind.
+func (p Position) Advance(e *Event) Position {
+	switch {
+	case e.CreateTime.After(p.Time):
+		return Position{Time: e.CreateTime, Keys: []string{EventKey(e)}}
+	case e.CreateTime.Equal(p.Time) && !p.Contains(e):
+		keys := append(append([]string{}, p.Keys...), EventKey(e))
+		return Position{Time: p.Time, Keys: keys}
+	default:
+		return p
+	}
+}
+
+// Cursor reads the events of a stream service in batches, in the order they
+// were created, from a position. Events sharing a create time across batches
+// are read once.
+type Cursor struct {
+	stream StreamService
+	pos    Position
+	size   int
+}
+
+// NewCursor returns a new Cursor reading batches of size events from the position.
+func NewCursor(stream StreamService, pos Position, size int) *Cursor {
+	if size <= 0 {
+		size = 100
+	}
+	return &Cursor{stream: stream, pos: pos, size: size}
+}
+
+// Position returns the position after the events read.
+func (c *Cursor) Position() Position {
+	return c.pos
+}
+
+// Next returns the next batch of events, no events once all were read.
+func (c *Cursor) Next(ctx context.Context) ([]*Event, error) {
+	// Read from the position time on, the events already read with that
+	// create time are skipped.
+	p := StreamParams{Limit: c.size}
+	if !c.pos.Time.IsZero() {
+		p.After = c.pos.Time.Add(-time.Nanosecond)
+	}
+	for {
+		events, err := c.stream.Stream(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		var out []*Event
+		for _, e := range events {
+			if c.pos.Contains(e) {
+				continue
+			}
+			c.pos = c.pos.Advance(e)
+			out = append(out, e)
+		}
+		if len(out) > 0 || len(events) < p.Limit {
+			return out, nil
+		}
+		// The whole batch was already read, all with the same create time.
+		p.Limit *= 2
+	}
+}
+
+// Ensure Checkpoints implements CheckpointService.
+var _ CheckpointService = &Checkpoints{}
+
+// Checkpoints is an in-memory CheckpointService.
+type Checkpoints struct {
+	mu sync.Mutex
+	m  map[string]Position
+}
+
+// NewCheckpoints creates a new Checkpoints
+func NewCheckpoints() *Checkpoints {
+	return &Checkpoints{
+		m: make(map[string]Position),
+	}
+}
+
+// Get returns the checkpoint of the subscription.
+func (s *Checkpoints) Get(ctx context.Context, name string) (Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m[name], nil
+}
+
+// Set stores the checkpoint of the subscription.
+func (s *Checkpoints) Set(ctx context.Context, name string, position Position) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[name] = position
+	return nil
+}
+
+// DurableOptions is a configurable object for durable worker func
+type DurableOptions struct {
+	WorkerOptions
+	// Name of the durable subscription, required.
+	Name string
+	// Stream from where missed events are read, required.
+	Stream StreamService
+	// Checkpoints persists the subscription position, required.
+	Checkpoints CheckpointService
+	// BatchSize (optional) number of events read from the stream at once, defaults to 100.
+	BatchSize int
+	// Lookback (optional) catches up from this long before the checkpoint, so that
+	// events written by hosts with a clock behind are not missed after a restart.
+	// The events created within the lookback are processed again.
+	Lookback time.Duration
+}
+
+// liveWindow is how long before the catch-up starts the events read from the
+// stream may also be received live, events created earlier are not expected
+// in the subscribed channel.
+const liveWindow = time.Minute
+
+// DurableWorker catches up with the events created since the last checkpoint of
+// the named subscription and then waits for live events from the subscribed channel.
+// The checkpoint is saved after the actions of each event run, so that after a
+// restart the subscription resumes from the last processed event.
+//
+// The channel must be subscribed before calling DurableWorker, so that live
+// events published during catch-up are not missed. Live events also read during
+// catch-up are processed once, any other live event is processed whatever its
+// create time. Events are processed one at a time in order to keep a single
+// checkpoint, so PoolSize is ignored, and in the order they were published, so
+// the event priority is ignored.
+func DurableWorker(ctx context.Context, c chan *Event, opts DurableOptions) error {
+	if c == nil {
+		panic("pubsub: subscribe using nil channel")
+	}
+	if opts.Name == "" {
+		return ErrSubscriptionNameRequired
+	}
+	if opts.Stream == nil || opts.Checkpoints == nil {
+		return ErrStreamServiceNotConfigured
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	handlers.Lock()
+	h, ok := handlers.m[c]
+	handlers.Unlock()
+	if !ok {
+		return nil
+	}
+
+	position, err := opts.Checkpoints.Get(ctx, opts.Name)
+	if err != nil {
+		return err
+	}
+
+	// handle runs actions for the event and moves the checkpoint forward.
+	handle := func(e *Event) error {
+		opts.handle(ctx, h, e)
+		next := position.Advance(e)
+		if err := opts.Checkpoints.Set(ctx, opts.Name, next); err != nil {
+			return err
+		}
+		position = next
+		return nil
+	}
+
+	// Catch up from the event store, holding live events meanwhile.
+	start := time.Now()
+	from := position
+	if opts.Lookback > 0 && !from.Time.IsZero() {
+		from = Position{Time: from.Time.Add(-opts.Lookback)}
+	}
+	cursor := NewCursor(opts.Stream, from, opts.BatchSize)
+	// keys of the events read from the stream that may also be received live
+	caught := make(map[string]bool)
+	var pending []*Event
+	n := 0
+	for {
+		events, err := cursor.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			break
+		}
+		for _, e := range events {
+			pending = drain(c, pending)
+			if err := handle(e); err != nil {
+				return err
+			}
+			if e.CreateTime.After(start.Add(-liveWindow)) {
+				caught[EventKey(e)] = true
+			}
+		}
+		n += len(events)
+	}
+	log.Printf("pubsub: durable subscription %s caught up with %d events - duration: %v", opts.Name, n, time.Now().Sub(start))
+
+	// live runs actions for a live event unless it was already read from the stream.
+	live := func(e *Event) error {
+		if k := EventKey(e); caught[k] {
+			delete(caught, k)
+			return nil
+		}
+		return handle(e)
+	}
+
+	for _, e := range drain(c, pending) {
+		if err := live(e); err != nil {
+			return err
+		}
+	}
+
+	// Switch to live events.
+	loop(ctx, c, false, func(e *Event) {
+		if err := live(e); err != nil {
+			log.Printf("pubsub: durable subscription %s checkpoint failed for event %s with aggregate %s version %d > error %v", opts.Name, e.Topic, e.AggregateID, e.Version, err)
+		}
+	})
+	return nil
+}
+
+// drain appends the events available in the channel without blocking.
+func drain(c chan *Event, events []*Event) []*Event {
+	for {
+		select {
+		case e := <-c:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
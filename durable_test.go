@@ -0,0 +1,204 @@
+package hippo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paulormart/assert"
+)
+
+// streamFn is a StreamService backed by a function.
+type streamFn func(ctx context.Context, p StreamParams) ([]*Event, error)
+
+func (fn streamFn) Stream(ctx context.Context, p StreamParams) ([]*Event, error) {
+	return fn(ctx, p)
+}
+
+func TestPubSub_DurableWorker(t *testing.T) {
+	now := time.Now().UTC()
+	var events []*Event
+	for i := 0; i < 4; i++ {
+		e := NewEvent("user_created", "123ABC")
+		e.Version = int64(i + 1)
+		e.CreateTime = now.Add(time.Duration(i) * time.Millisecond)
+		events = append(events, e)
+	}
+
+	// Only the first three events are already in the store.
+	stream := func(ctx context.Context, p StreamParams) ([]*Event, error) {
+		var out []*Event
+		for _, e := range events[:3] {
+			if e.CreateTime.After(p.After) && (p.Limit == 0 || len(out) < p.Limit) {
+				out = append(out, e)
+			}
+		}
+		return out, nil
+	}
+
+	// The first event was processed before the restart.
+	checkpoints := NewCheckpoints()
+	checkpoints.Set(context.Background(), "projection", Position{}.Advance(events[0]))
+
+	mu := sync.Mutex{}
+	var versions []int64
+	a := func(ctx context.Context, e *Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		versions = append(versions, e.Version)
+		return nil
+	}
+
+	c1 := make(chan *Event, 2)
+	Subscribe(c1, ActionTopics{"user_created": []ActionFn{a}})
+	defer Unsubscribe(c1)
+
+	// Live events published while the durable worker was not running.
+	publish(events[2])
+	publish(events[3])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- DurableWorker(ctx, c1, DurableOptions{
+			Name:        "projection",
+			Stream:      streamFn(stream),
+			Checkpoints: checkpoints,
+			BatchSize:   1,
+		})
+	}()
+
+	for i := 0; i < 100; i++ {
+		mu.Lock()
+		n := len(versions)
+		mu.Unlock()
+		if n >= 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []int64{2, 3, 4}, versions)
+	pos, _ := checkpoints.Get(context.Background(), "projection")
+	assert.Equal(t, events[3].CreateTime, pos.Time)
+}
+
+// streamOf returns a StreamService over the events, ordered by create time.
+func streamOf(events []*Event) streamFn {
+	return func(ctx context.Context, p StreamParams) ([]*Event, error) {
+		var out []*Event
+		for _, e := range events {
+			if e.CreateTime.After(p.After) && (p.Limit == 0 || len(out) < p.Limit) {
+				out = append(out, e)
+			}
+		}
+		return out, nil
+	}
+}
+
+func TestCursor_SameCreateTime(t *testing.T) {
+	now := time.Now().UTC()
+	var events []*Event
+	for i := 0; i < 6; i++ {
+		e := NewEvent("user_created", string(rune('A'+i)))
+		e.Version = 1
+		// All events but the last are created at the same time.
+		e.CreateTime = now
+		if i == 5 {
+			e.CreateTime = now.Add(time.Millisecond)
+		}
+		events = append(events, e)
+	}
+
+	// read returns all the events read by a cursor from the position.
+	read := func(pos Position) ([]*Event, Position) {
+		cursor := NewCursor(streamOf(events), pos, 2)
+		var out []*Event
+		for {
+			batch, err := cursor.Next(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(batch) == 0 {
+				return out, cursor.Position()
+			}
+			out = append(out, batch...)
+		}
+	}
+
+	all, pos := read(Position{})
+	assert.Equal(t, events, all)
+	assert.Equal(t, Position{Time: events[5].CreateTime, Keys: []string{EventKey(events[5])}}, pos)
+
+	// Resume from the middle of the events sharing the create time.
+	var mid Position
+	for _, e := range events[:3] {
+		mid = mid.Advance(e)
+	}
+	rest, _ := read(mid)
+	assert.Equal(t, events[3:], rest)
+}
+
+func TestPubSub_DurableWorkerLive(t *testing.T) {
+	now := time.Now().UTC()
+	stored := NewEvent("user_created", "123ABC")
+	stored.Version = 1
+	stored.CreateTime = now
+
+	// Live event written by a host with a clock behind.
+	late := NewEvent("user_created", "456DEF")
+	late.Version = 1
+	late.CreateTime = now.Add(-time.Second)
+
+	mu := sync.Mutex{}
+	var processed []*Event
+	a := func(ctx context.Context, e *Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed = append(processed, e)
+		return nil
+	}
+
+	c1 := make(chan *Event, 2)
+	Subscribe(c1, ActionTopics{"user_created": []ActionFn{a}})
+	defer Unsubscribe(c1)
+
+	// The stored event is also received live.
+	publish(stored)
+	publish(late)
+
+	checkpoints := NewCheckpoints()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- DurableWorker(ctx, c1, DurableOptions{
+			Name:        "projection",
+			Stream:      streamOf([]*Event{stored}),
+			Checkpoints: checkpoints,
+		})
+	}()
+
+	for i := 0; i < 100; i++ {
+		mu.Lock()
+		n := len(processed)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []*Event{stored, late}, processed)
+	// The checkpoint does not move back.
+	pos, _ := checkpoints.Get(context.Background(), "projection")
+	assert.Equal(t, stored.CreateTime, pos.Time)
+}
@@ -16,6 +16,13 @@ const (
 	ErrEmptyState               = Error("aggregate with empty state")
 	ErrInvalidEventFormat       = Error("event data is not encoded in the right format")
 	ErrInvalidSchema            = Error("invalid schema schema to decode event data")
+	ErrEventNotFound            = Error("event not found")
+	ErrVersionNotContinuous     = Error("event version is not continuous")
+	ErrEventMismatch            = Error("event differs from the event stored with the same version")
+	ErrInvalidSignature         = Error("invalid event signature")
+	ErrSignerNotConfigured      = Error("signer is not configured")
+	ErrUpcasterCycle            = Error("upcasters applied in a cycle")
+	ErrEventTooLarge            = Error("event exceeds the maximum size")
 )
 
 // Cache errors.
@@ -24,6 +31,22 @@ const (
 	ErrKeyDoesNotExist           = Error("key does not exist")
 	ErrVersionFieldDoesNotExist  = Error("invalid aggregate key - version field does not exist")
 	ErrStateFieldDoesNotExist    = Error("invalid aggregate key - state field does not exist")
+	ErrEventFieldDoesNotExist    = Error("invalid stream message - event field does not exist")
+	ErrCacheNamespaceRequired    = Error("cache namespace required")
+)
+
+// Outbox errors.
+const (
+	ErrOutboxServiceNotConfigured = Error("outbox service is not configured")
+)
+
+// Pubsub errors.
+const (
+	ErrDeadLetterNotFound         = Error("dead letter not found")
+	ErrSubscriptionNameRequired   = Error("subscription name required")
+	ErrStreamServiceNotConfigured = Error("stream and checkpoint services are not configured")
+	ErrConsumerGroupRequired      = Error("consumer group and name required")
+	ErrSubscriberTooSlow          = Error("subscriber too slow, events dropped")
 )
 
 // Error represents a HIPPO error.
@@ -15,6 +15,34 @@ type EventService interface {
 	List(ctx context.Context, p Params) ([]*Event, error)
 }
 
+// OutboxService represents a service for managing events persisted
+// but not yet published to subscribers.
+type OutboxService interface {
+	Pending(ctx context.Context, limit int) ([]*Event, error)
+	MarkPublished(ctx context.Context, e *Event) error
+}
+
+// StreamService represents a service for reading events across all aggregates
+// in the order they were created.
+type StreamService interface {
+	Stream(ctx context.Context, p StreamParams) ([]*Event, error)
+}
+
+// AppendService represents a service for appending several events of an aggregate
+// at once, either all or none of them are persisted.
+type AppendService interface {
+	Append(ctx context.Context, events []*Event) error
+}
+
+// AtomicService represents an event store whose Create rejects, with
+// ErrConcurrencyException, an event that does not follow the last version of
+// its aggregate, checking and persisting it atomically for all the writers of
+// the store. It is required by the AtomicAppend concurrency mode.
+type AtomicService interface {
+	// Atomic reports whether the version checks hold for all the writers.
+	Atomic() bool
+}
+
 // Format enumerator
 type Format int32
 
@@ -32,20 +60,22 @@ type Event struct {
 	AggregateID string
 	// Version of the aggregate, useful when using concurrency writes. (read-only)
 	Version int64
-	// Schema of the aggregate.
+	// Schema of the aggregate. Events of old schemas are upcasted when loaded, see Upcasters.
 	Schema string
 	// Format of the encoded type of the aggregate data
 	Format Format
 	// Data raw object data.
 	Data []byte
-	// Priority of the event, where 0 is the highest priority.
+	// Priority of the event, where 0 is the highest priority. Workers process the
+	// higher priority events waiting in the channel first, see Worker.
 	Priority int32
-	// TODO: Signature includes SHA1 signature computed against it's contents and signature
-	// of the previous event. (not implemented yet)
+	// Signature includes SHA-256 signature, or HMAC-SHA256 if signed with a key, computed
+	// against it's contents and signature of the previous event. Set by Dispatch if a
+	// Signer is registered.
 	Signature string
-	// TODO: Origin of the event. e.g. service name. (not implemented yet)
+	// Origin of the event. e.g. service name. Set by Dispatch from the context, see NewOriginContext.
 	OriginName string
-	// TODO: Origin of the event. e.g. service ip address / browser. (not implemented yet)
+	// Origin of the event. e.g. service ip address / browser. Set by Dispatch from the context.
 	OriginIP string
 	// Metadata
 	Metadata map[string]string
@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/aukbit/hippo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codesByError maps hippo errors to the gRPC status codes sent to clients.
+var codesByError = map[hippo.Error]codes.Code{
+	hippo.ErrParamsIDRequired:           codes.InvalidArgument,
+	hippo.ErrNotImplemented:             codes.Unimplemented,
+	hippo.ErrAggregateIDCanNotBeEmpty:   codes.InvalidArgument,
+	hippo.ErrFormatNotProvided:          codes.InvalidArgument,
+	hippo.ErrConcurrencyException:       codes.Aborted,
+	hippo.ErrAggregateIDWithoutEvents:   codes.NotFound,
+	hippo.ErrInvalidEventFormat:         codes.InvalidArgument,
+	hippo.ErrInvalidSchema:              codes.InvalidArgument,
+	hippo.ErrEventNotFound:              codes.NotFound,
+	hippo.ErrStreamServiceNotConfigured: codes.FailedPrecondition,
+	hippo.ErrSubscriberTooSlow:          codes.ResourceExhausted,
+}
+
+// toStatus converts err to a gRPC status error. Hippo errors keep their
+// message so that clients are able to map them back.
+func toStatus(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if e, ok := err.(hippo.Error); ok {
+		if code, ok := codesByError[e]; ok {
+			return status.Error(code, e.Error())
+		}
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// fromStatus converts a gRPC status error back to the hippo error with the same
+// message, or to the context error if the call was canceled or timed out.
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch s.Code() {
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	}
+	if e := hippo.Error(s.Message()); codesByError[e] == s.Code() {
+		return e
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"io"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+	"google.golang.org/grpc/metadata"
+)
+
+// Ensure EventService implements hippo.EventService and hippo.AtomicService.
+var _ hippo.EventService = &EventService{}
+var _ hippo.AtomicService = &EventService{}
+
+// EventService represents a service for managing a remote aggregate store
+// through the Command and Query gRPC services.
+type EventService struct {
+	// remote store
+	store *StoreService
+}
+
+// Create persists the event in the remote store. A version that does not follow
+// the last version is rejected with hippo.ErrConcurrencyException if the store of
+// the server checks the version, e.g. the InfluxDB store. The name of the origin
+// carried by the context is sent as the OriginNameKey metadata.
+func (s *EventService) Create(ctx context.Context, e *hippo.Event) error {
+	ctx, cancel := s.store.context(ctx)
+	defer cancel()
+
+	if o, ok := hippo.OriginFromContext(ctx); ok && o.Name != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, OriginNameKey, o.Name)
+	}
+
+	pb, err := internal.EncodeEvent(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.store.command.CreateEvent(ctx, &internal.CreateEventRequest{Event: pb})
+	return fromStatus(err)
+}
+
+// Atomic reports whether the store of the server is declared to check versions
+// atomically for all its writers.
+func (s *EventService) Atomic() bool {
+	return s.store.atomic
+}
+
+// GetLastVersion returns the version of the last event of the aggregate, or zero
+// if the aggregate has no events.
+func (s *EventService) GetLastVersion(ctx context.Context, aggregateID string) (int64, error) {
+	if aggregateID == "" {
+		return 0, hippo.ErrParamsIDRequired
+	}
+
+	ctx, cancel := s.store.context(ctx)
+	defer cancel()
+
+	resp, err := s.store.query.GetLastVersion(ctx, &internal.GetLastVersionRequest{AggregateId: aggregateID})
+	if err != nil {
+		return 0, fromStatus(err)
+	}
+	return resp.GetVersion(), nil
+}
+
+// List returns the events of the aggregate, optionally between versions.
+func (s *EventService) List(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+	if p.ID == "" {
+		return nil, hippo.ErrParamsIDRequired
+	}
+
+	ctx, cancel := s.store.context(ctx)
+	defer cancel()
+
+	stream, err := s.store.query.ListEvents(ctx, &internal.ListEventsRequest{
+		AggregateId: p.ID,
+		VersionMin:  p.FromVersion,
+		VersionMax:  p.ToVersion,
+		OriginName:  p.OriginName,
+		OriginIp:    p.OriginIP,
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	var events []*hippo.Event
+	for {
+		pb, err := stream.Recv()
+		if err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return nil, fromStatus(err)
+		}
+		e := &hippo.Event{}
+		if err := internal.DecodeEvent(pb, e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+}
@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/aukbit/hippo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// OriginNameKey is the metadata key of the name of the calling service.
+const OriginNameKey = "hippo-origin-name"
+
+// UnaryOriginInterceptor returns a server interceptor that carries the origin of
+// the call in the context, the calling service from the OriginNameKey metadata and
+// the ip address of the peer, so that it is stamped on the created events that
+// are not signed yet.
+func UnaryOriginInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(originContext(ctx), req)
+	}
+}
+
+// StreamOriginInterceptor returns a server interceptor that carries the origin
+// of the call in the stream context, see UnaryOriginInterceptor.
+func StreamOriginInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &originStream{ServerStream: ss, ctx: originContext(ss.Context())})
+	}
+}
+
+// originStream wraps a server stream to override its context.
+type originStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context carrying the origin.
+func (s *originStream) Context() context.Context {
+	return s.ctx
+}
+
+// originContext returns a new context carrying the origin of the call.
+func originContext(ctx context.Context) context.Context {
+	var o hippo.Origin
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(OriginNameKey); len(v) > 0 {
+			o.Name = v[0]
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		o.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(o.IP); err == nil {
+			o.IP = host
+		}
+	}
+	return hippo.NewOriginContext(ctx, o)
+}
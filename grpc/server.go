@@ -0,0 +1,263 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"net"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+)
+
+var _ internal.CommandServer = &Server{}
+var _ internal.QueryServer = &Server{}
+
+// Server exposes a hippo.StoreService over the Command and Query gRPC services,
+// so that several services are able to share one event store.
+type Server struct {
+	store     hippo.StoreService
+	publisher hippo.Publisher
+	outbox    hippo.OutboxService
+	server    *grpc.Server
+}
+
+// NewServer creates a new Server for the event store.
+func NewServer(store hippo.StoreService, opts ...grpc.ServerOption) *Server {
+	s := &Server{
+		store:     store,
+		publisher: hippo.Bus{},
+		server:    grpc.NewServer(opts...),
+	}
+	s.Register(s.server)
+	return s
+}
+
+// Register registers the Command and Query services in a gRPC server,
+// e.g. to serve them together with other services.
+func (s *Server) Register(srv *grpc.Server) {
+	internal.RegisterCommandServer(srv, s)
+	internal.RegisterQueryServer(srv, s)
+}
+
+// RegisterPublisher assigns the publisher of the created events, defaults to
+// the in-process bus from where Subscribe streams live events.
+func (s *Server) RegisterPublisher(p hippo.Publisher) {
+	s.publisher = p
+}
+
+// RegisterOutboxService assigns the outbox of the event store, where the created
+// events are marked as published once published, otherwise the relay would
+// publish them again.
+func (s *Server) RegisterOutboxService(outbox hippo.OutboxService) {
+	s.outbox = outbox
+}
+
+// Serve accepts connections on the listener until Close is called.
+func (s *Server) Serve(ln net.Listener) error {
+	return s.server.Serve(ln)
+}
+
+// Close stops the server once pending RPCs are finished.
+func (s *Server) Close() error {
+	s.server.GracefulStop()
+	return nil
+}
+
+// CreateEvent persists the event in the event store.
+func (s *Server) CreateEvent(ctx context.Context, req *internal.CreateEventRequest) (*empty.Empty, error) {
+	if req.GetEvent() == nil {
+		return nil, toStatus(hippo.ErrInvalidEventFormat)
+	}
+	var e hippo.Event
+	if err := internal.DecodeEvent(req.GetEvent(), &e); err != nil {
+		return nil, toStatus(hippo.ErrInvalidEventFormat)
+	}
+	if e.AggregateID == "" {
+		return nil, toStatus(hippo.ErrAggregateIDCanNotBeEmpty)
+	}
+	// Stamp the origin of the call, events signed by the client keep their own.
+	hippo.StampOrigin(ctx, &e)
+	if err := s.store.EventService().Create(ctx, &e); err != nil {
+		return nil, toStatus(err)
+	}
+	s.publish(ctx, &e)
+	return &empty.Empty{}, nil
+}
+
+// publish publishes the persisted event to subscribers and marks it as published
+// in the outbox, if any. Without an outbox an event that fails to publish is lost
+// for the subscribers, with an outbox the relay publishes it again.
+func (s *Server) publish(ctx context.Context, e *hippo.Event) {
+	if err := s.publisher.Publish(ctx, e); err != nil {
+		log.Printf("grpc: event %s with aggregate %s version %d not published > error %v", e.Topic, e.AggregateID, e.Version, err)
+		return
+	}
+	if s.outbox == nil {
+		return
+	}
+	if err := s.outbox.MarkPublished(ctx, e); err != nil {
+		log.Printf("grpc: event %s with aggregate %s version %d not marked as published > error %v", e.Topic, e.AggregateID, e.Version, err)
+	}
+}
+
+// SnapshotEvent is not implemented since the event store does not hold snapshots.
+func (s *Server) SnapshotEvent(ctx context.Context, req *internal.SnapshotEventRequest) (*empty.Empty, error) {
+	return nil, toStatus(hippo.ErrNotImplemented)
+}
+
+// GetEvent returns the event of the aggregate with the given version.
+func (s *Server) GetEvent(ctx context.Context, req *internal.GetEventRequest) (*internal.Event, error) {
+	if req.GetAggregateId() == "" {
+		return nil, toStatus(hippo.ErrParamsIDRequired)
+	}
+	events, err := s.store.EventService().List(ctx, hippo.Params{
+		ID:          req.GetAggregateId(),
+		FromVersion: req.GetVersion(),
+		ToVersion:   req.GetVersion(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	for _, e := range events {
+		if e.Version == req.GetVersion() {
+			pb, err := internal.EncodeEvent(e)
+			if err != nil {
+				return nil, toStatus(err)
+			}
+			return pb, nil
+		}
+	}
+	return nil, toStatus(hippo.ErrEventNotFound)
+}
+
+// ListEvents streams the events of the aggregate, optionally between versions.
+func (s *Server) ListEvents(req *internal.ListEventsRequest, stream internal.Query_ListEventsServer) error {
+	if req.GetAggregateId() == "" {
+		return toStatus(hippo.ErrParamsIDRequired)
+	}
+	events, err := s.store.EventService().List(stream.Context(), hippo.Params{
+		ID:          req.GetAggregateId(),
+		FromVersion: req.GetVersionMin(),
+		ToVersion:   req.GetVersionMax(),
+		OriginName:  req.GetOriginName(),
+		OriginIP:    req.GetOriginIp(),
+	})
+	if err != nil {
+		return toStatus(err)
+	}
+	for _, e := range events {
+		pb, err := internal.EncodeEvent(e)
+		if err != nil {
+			return toStatus(err)
+		}
+		if err := stream.Send(pb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLastVersion returns the version of the last event of the aggregate, or zero
+// if the aggregate has no events.
+func (s *Server) GetLastVersion(ctx context.Context, req *internal.GetLastVersionRequest) (*internal.GetLastVersionResponse, error) {
+	if req.GetAggregateId() == "" {
+		return nil, toStatus(hippo.ErrParamsIDRequired)
+	}
+	v, err := s.store.EventService().GetLastVersion(ctx, req.GetAggregateId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &internal.GetLastVersionResponse{Version: v}, nil
+}
+
+// Subscribe streams the events of the topics and aggregate published to the
+// in-process bus until the client cancels. If after is set, the events created
+// since then are read from the event store first, which must implement hippo.StreamService.
+// A client that falls behind is disconnected with hippo.ErrSubscriberTooSlow instead
+// of blocking the publishers, it may subscribe again after its last event.
+func (s *Server) Subscribe(req *internal.SubscribeRequest, stream internal.Query_SubscribeServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	var slow int32
+
+	// send streams an event to the client, stopping the subscription on failure.
+	send := func(ctx context.Context, e *hippo.Event) error {
+		pb, err := internal.EncodeEvent(e)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(pb); err != nil {
+			cancel()
+			return err
+		}
+		return nil
+	}
+
+	// Subscribe before catching up, so that live events are not missed.
+	c := make(chan *hippo.Event, 100)
+	hippo.SubscribeFilter(c, match(req), []hippo.ActionFn{send})
+	hippo.OnOverflow(c, func(e *hippo.Event) {
+		if atomic.CompareAndSwapInt32(&slow, 0, 1) {
+			log.Printf("grpc: subscriber disconnected, event %s with aggregate %s version %d dropped", e.Topic, e.AggregateID, e.Version)
+		}
+		cancel()
+	})
+	defer hippo.Unsubscribe(c)
+
+	// tooSlow returns the error of a subscriber disconnected for falling behind.
+	tooSlow := func(err error) error {
+		if atomic.LoadInt32(&slow) == 1 {
+			return toStatus(hippo.ErrSubscriberTooSlow)
+		}
+		return err
+	}
+
+	if req.GetAfter() == nil {
+		hippo.Worker(ctx, c)
+		return tooSlow(nil)
+	}
+
+	after, err := ptypes.Timestamp(req.GetAfter())
+	if err != nil {
+		return toStatus(hippo.ErrInvalidEventFormat)
+	}
+	st, ok := s.store.EventService().(hippo.StreamService)
+	if !ok {
+		return toStatus(hippo.ErrStreamServiceNotConfigured)
+	}
+	// Events created at the after time were already streamed.
+	checkpoints := hippo.NewCheckpoints()
+	if err := checkpoints.Set(ctx, "subscribe", hippo.Position{Time: after.Add(time.Nanosecond)}); err != nil {
+		return toStatus(err)
+	}
+	return tooSlow(toStatus(hippo.DurableWorker(ctx, c, hippo.DurableOptions{
+		Name:        "subscribe",
+		Stream:      st,
+		Checkpoints: checkpoints,
+	})))
+}
+
+// match returns a filter of the events of the topics and aggregate of the request.
+func match(req *internal.SubscribeRequest) hippo.FilterFn {
+	return func(e *hippo.Event) bool {
+		if req.GetAggregateId() != "" && req.GetAggregateId() != e.AggregateID {
+			return false
+		}
+		if len(req.GetTopics()) == 0 {
+			return true
+		}
+		for _, t := range req.GetTopics() {
+			if ok, _ := path.Match(t, e.Topic); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,270 @@
+package grpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	hgrpc "github.com/aukbit/hippo/grpc"
+	"github.com/aukbit/hippo/internal"
+	"github.com/aukbit/hippo/mock"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// MustOpenServer serves the store over an in-memory connection and returns a client connection.
+func MustOpenServer(t *testing.T, store hippo.StoreService, opts ...grpc.ServerOption) (*grpc.ClientConn, func()) {
+	return MustServe(t, hgrpc.NewServer(store, opts...))
+}
+
+// MustServe serves the server over an in-memory listener and returns a connection to it.
+func MustServe(t *testing.T, s *hgrpc.Server) (*grpc.ClientConn, func()) {
+	ln := bufconn.Listen(1024 * 1024)
+	go s.Serve(ln)
+
+	dialer := func(ctx context.Context, addr string) (net.Conn, error) {
+		return ln.Dial()
+	}
+	conn, err := grpc.DialContext(context.Background(), "bufnet", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, func() {
+		conn.Close()
+		s.Close()
+	}
+}
+
+func TestServer_CreateEvent(t *testing.T) {
+	var ss mock.StoreService
+	var es mock.EventService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	// Mock EventService.Create(), version 2 already exists.
+	var created []*hippo.Event
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		if e.Version == 2 {
+			return hippo.ErrConcurrencyException
+		}
+		created = append(created, e)
+		return nil
+	}
+
+	conn, close := MustOpenServer(t, &ss)
+	defer close()
+	clt := internal.NewCommandClient(conn)
+	ctx := context.Background()
+
+	e := hippo.NewEventProto("user_created", rand.String(10), &pb.User{Name: "Han"})
+	e.Version = 1
+	ev, err := internal.EncodeEvent(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clt.CreateEvent(ctx, &internal.CreateEventRequest{Event: ev}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(created))
+	assert.Equal(t, e.AggregateID, created[0].AggregateID)
+	assert.Equal(t, e.Data, created[0].Data)
+	assert.Equal(t, true, e.CreateTime.Equal(created[0].CreateTime))
+
+	// Concurrency exception is mapped to aborted.
+	ev.Version = 2
+	_, err = clt.CreateEvent(ctx, &internal.CreateEventRequest{Event: ev})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	assert.Equal(t, hippo.ErrConcurrencyException.Error(), status.Convert(err).Message())
+
+	// Snapshots are not implemented.
+	_, err = clt.SnapshotEvent(ctx, &internal.SnapshotEventRequest{Event: ev})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+// Ensure created events are marked as published in the outbox.
+func TestServer_CreateEventOutbox(t *testing.T) {
+	var ss mock.StoreService
+	var es mock.EventService
+	var outbox mock.OutboxService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		return nil
+	}
+	var published []int64
+	outbox.MarkPublishedFn = func(ctx context.Context, e *hippo.Event) error {
+		published = append(published, e.Version)
+		return nil
+	}
+
+	s := hgrpc.NewServer(&ss)
+	s.RegisterOutboxService(&outbox)
+	conn, close := MustServe(t, s)
+	defer close()
+
+	e := hippo.NewEventProto("user_created", rand.String(10), &pb.User{Name: "Han"})
+	e.Version = 1
+	ev, err := internal.EncodeEvent(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := internal.NewCommandClient(conn).CreateEvent(context.Background(), &internal.CreateEventRequest{Event: ev}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int64{1}, published)
+}
+
+func TestServer_Query(t *testing.T) {
+	var ss mock.StoreService
+	var es mock.EventService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	id := rand.String(10)
+	var events []*hippo.Event
+	for i := 1; i <= 3; i++ {
+		e := hippo.NewEventProto("user_updated", id, &pb.User{Name: "Chewie"})
+		e.Version = int64(i)
+		events = append(events, e)
+	}
+
+	// Mock EventService.List()
+	var params hippo.Params
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		params = p
+		var out []*hippo.Event
+		for _, e := range events {
+			if p.ToVersion == 0 || (e.Version >= p.FromVersion && e.Version <= p.ToVersion) {
+				out = append(out, e)
+			}
+		}
+		return out, nil
+	}
+
+	conn, close := MustOpenServer(t, &ss)
+	defer close()
+	clt := internal.NewQueryClient(conn)
+	ctx := context.Background()
+
+	// Get event version 2.
+	ev, err := clt.GetEvent(ctx, &internal.GetEventRequest{AggregateId: id, Version: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(2), ev.GetVersion())
+	assert.Equal(t, hippo.Params{ID: id, FromVersion: 2, ToVersion: 2}, params)
+
+	// Get event version that does not exist.
+	_, err = clt.GetEvent(ctx, &internal.GetEventRequest{AggregateId: id, Version: 4})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// List events between versions.
+	stream, err := clt.ListEvents(ctx, &internal.ListEventsRequest{AggregateId: id, VersionMin: 2, VersionMax: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []int64
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, ev.GetVersion())
+	}
+	assert.Equal(t, []int64{2, 3}, versions)
+
+	// Aggregate id is required.
+	_, err = clt.GetEvent(ctx, &internal.GetEventRequest{Version: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_Origin(t *testing.T) {
+	var ss mock.StoreService
+	var es mock.EventService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	// Mock EventService.Create() and List() filtered by origin.
+	var created []*hippo.Event
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		created = append(created, e)
+		return nil
+	}
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		var out []*hippo.Event
+		for _, e := range created {
+			if p.Match(e) {
+				out = append(out, e)
+			}
+		}
+		return out, nil
+	}
+
+	conn, close := MustOpenServer(t, &ss, grpc.UnaryInterceptor(hgrpc.UnaryOriginInterceptor()))
+	defer close()
+	ctx := context.Background()
+
+	// Create events, one of them from a calling service. The origin set by
+	// the client is overridden.
+	id := rand.String(10)
+	for i, name := range []string{"", "billing"} {
+		e := hippo.NewEventProto("user_updated", id, &pb.User{Name: "Han"})
+		e.Version = int64(i + 1)
+		e.OriginName = "admin"
+		e.OriginIP = "10.0.0.9"
+		ev, err := internal.EncodeEvent(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := ctx
+		if name != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, hgrpc.OriginNameKey, name)
+		}
+		if _, err := internal.NewCommandClient(conn).CreateEvent(ctx, &internal.CreateEventRequest{Event: ev}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal(t, 2, len(created))
+	assert.Equal(t, "", created[0].OriginName)
+	assert.Equal(t, "billing", created[1].OriginName)
+	assert.Equal(t, "bufconn", created[1].OriginIP)
+
+	// List events of one origin.
+	stream, err := internal.NewQueryClient(conn).ListEvents(ctx, &internal.ListEventsRequest{AggregateId: id, OriginName: "billing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []int64
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, ev.GetVersion())
+	}
+	assert.Equal(t, []int64{2}, versions)
+}
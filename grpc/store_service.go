@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+	"google.golang.org/grpc"
+)
+
+var _ hippo.StoreService = &StoreService{}
+
+// StoreService holds event service and the connection to a remote hippo
+// event store served by Server.
+type StoreService struct {
+	// Services
+	eventService EventService
+
+	// connection to the remote event store
+	conn *grpc.ClientConn
+
+	// clients of the remote event store
+	command internal.CommandClient
+	query   internal.QueryClient
+
+	// timeout of the calls without deadline
+	timeout time.Duration
+
+	// atomic declares that the store of the server checks versions atomically
+	atomic bool
+}
+
+// Config represents a configuration to connect to a remote hippo event store
+type Config struct {
+	// Addr should be of the form "host:port", defaults to "localhost:50051"
+	Addr string
+
+	// Timeout is the deadline of the calls whose context has no deadline,
+	// defaults to 5s. Deadlines of the context are always propagated.
+	Timeout time.Duration
+
+	// DialOptions configure the connection, e.g. transport credentials, optional.
+	// Defaults to an insecure connection.
+	DialOptions []grpc.DialOption
+
+	// Atomic declares that the store of the server checks the versions of the
+	// created events atomically for all its writers, e.g. an InfluxDB store
+	// configured as its single writer, so that hippo.AtomicAppend applies, optional.
+	Atomic bool
+}
+
+// NewStoreService creates a new StoreService
+func NewStoreService() *StoreService {
+	s := &StoreService{}
+	s.eventService.store = s
+	return s
+}
+
+// Connect connects to the remote event store, waiting until the connection is ready.
+func (s *StoreService) Connect(conf Config) error {
+	if conf.Addr == "" {
+		conf.Addr = "localhost:50051"
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = 5 * time.Second
+	}
+	opts := conf.DialOptions
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithInsecure()}
+	}
+	opts = append(opts, grpc.WithBlock())
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, conf.Addr, opts...)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.command = internal.NewCommandClient(conn)
+	s.query = internal.NewQueryClient(conn)
+	s.timeout = conf.Timeout
+	s.atomic = conf.Atomic
+	return nil
+}
+
+// Close closes the underlying connection.
+func (s *StoreService) Close() error {
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
+
+// context returns a context with the default timeout if ctx has no deadline.
+func (s *StoreService) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// EventService returns the event service associated with the client.
+func (s *StoreService) EventService() hippo.EventService { return &s.eventService }
@@ -0,0 +1,154 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aukbit/hippo"
+	hgrpc "github.com/aukbit/hippo/grpc"
+	"github.com/aukbit/hippo/mock"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// MustConnect serves the store over an in-memory connection and returns a connected StoreService.
+func MustConnect(t *testing.T, store hippo.StoreService, opts ...grpc.ServerOption) (*hgrpc.StoreService, func()) {
+	ln := bufconn.Listen(1024 * 1024)
+	srv := hgrpc.NewServer(store, opts...)
+	go srv.Serve(ln)
+
+	dialer := func(ctx context.Context, addr string) (net.Conn, error) {
+		return ln.Dial()
+	}
+	s := hgrpc.NewStoreService()
+	if err := s.Connect(hgrpc.Config{
+		Addr:        "bufnet",
+		DialOptions: []grpc.DialOption{grpc.WithContextDialer(dialer), grpc.WithInsecure()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return s, func() {
+		s.Close()
+		srv.Close()
+	}
+}
+
+// Ensure hippo.Client dispatches events to a remote store.
+func TestStoreService_Dispatch(t *testing.T) {
+	// Mock an in-memory event store rejecting versions out of order.
+	store := mock.NewEvents()
+	es := store.EventService()
+
+	s, close := MustConnect(t, store.StoreService())
+	defer close()
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(s)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	ctx := context.Background()
+
+	user := pb.User{Id: rand.String(10), Name: "Lando"}
+	for i := 1; i <= 2; i++ {
+		ev := hippo.NewEventProto("user_created", user.GetId(), &user)
+		agg, err := clt.Dispatch(ctx, ev, &user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, int64(i), agg.Version)
+	}
+
+	// Fetch aggregate from the remote store.
+	agg, err := clt.Fetch(ctx, user.GetId(), &pb.User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(2), agg.Version)
+	assert.Equal(t, "Lando", agg.State.(*pb.User).GetName())
+
+	// Concurrency exception is mapped back to the hippo error.
+	ev := hippo.NewEventProto("user_created", user.GetId(), &user)
+	ev.Version = 1
+	assert.Equal(t, hippo.ErrConcurrencyException, s.EventService().Create(ctx, ev))
+
+	// Last version is read without listing the events.
+	es.ListInvoked = false
+	v, err := s.EventService().GetLastVersion(ctx, user.GetId())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(2), v)
+	assert.Equal(t, false, es.ListInvoked)
+
+	// Aggregate without events.
+	v, err = s.EventService().GetLastVersion(ctx, rand.String(10))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(0), v)
+}
+
+// Ensure the deadline of the context is propagated to the remote store.
+func TestStoreService_Deadline(t *testing.T) {
+	var ss mock.StoreService
+	var es mock.EventService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	// Mock EventService.List(), waits for the propagated deadline.
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	s, close := MustConnect(t, &ss)
+	defer close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := s.EventService().List(ctx, hippo.Params{ID: rand.String(10)})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+// Ensure the origin of signed events dispatched to a remote store is kept, so
+// that their signatures are verified, and the origin name is sent to the server.
+func TestStoreService_DispatchOrigin(t *testing.T) {
+	store := mock.NewEvents()
+	s, close := MustConnect(t, store.StoreService(), grpc.UnaryInterceptor(hgrpc.UnaryOriginInterceptor()))
+	defer close()
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(s)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	clt.RegisterSigner(hippo.NewSigner([]byte("secret")))
+	ctx := hippo.NewOriginContext(context.Background(), hippo.Origin{Name: "billing"})
+
+	user := pb.User{Id: rand.String(10), Name: "Lando"}
+	if _, err := clt.Dispatch(ctx, hippo.NewEventProto("user_created", user.GetId(), &user), &user); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "billing", store.All()[0].OriginName)
+	assert.Equal(t, "", store.All()[0].OriginIP)
+	assert.Equal(t, nil, clt.Verify(ctx, user.GetId()))
+
+	// Unsigned events are stamped with the origin name sent by the client.
+	e := hippo.NewEventProto("user_updated", user.GetId(), &user)
+	e.Version = 2
+	e.OriginName = "admin"
+	if err := s.EventService().Create(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "billing", store.All()[1].OriginName)
+	assert.Equal(t, "bufconn", store.All()[1].OriginIP)
+}
@@ -0,0 +1,65 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+	"github.com/golang/protobuf/ptypes"
+)
+
+// SubscribeParams represents parameters to subscribe to the events of a remote store
+type SubscribeParams struct {
+	// AggregateID (optional) only events of the aggregate are streamed
+	AggregateID string
+	// After (optional) events created after this time are streamed before the
+	// live events, only live events are streamed if not set
+	After time.Time
+}
+
+// Subscribe streams the events of the topics from the remote store and runs the
+// respective actions, as Worker does for in-process events, until the context is
+// done or the stream fails. Topic patterns are supported.
+func (s *StoreService) Subscribe(ctx context.Context, p SubscribeParams, topics hippo.ActionTopics) error {
+	req := &internal.SubscribeRequest{
+		AggregateId: p.AggregateID,
+	}
+	for t := range topics {
+		req.Topics = append(req.Topics, string(t))
+	}
+	if !p.After.IsZero() {
+		after, err := ptypes.TimestampProto(p.After)
+		if err != nil {
+			return err
+		}
+		req.After = after
+	}
+
+	stream, err := s.query.Subscribe(ctx, req)
+	if err != nil {
+		return fromStatus(err)
+	}
+	for {
+		pb, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fromStatus(err)
+		}
+		e := &hippo.Event{}
+		if err := internal.DecodeEvent(pb, e); err != nil {
+			return err
+		}
+		for i, a := range topics.Actions(e.GetTopic()) {
+			start := time.Now()
+			if err := a(ctx, e); err != nil {
+				log.Printf("grpc: action %v failed for event %v with aggregate %s version %d - duration: %v > error %v", i, e.Topic, e.AggregateID, e.Version, time.Now().Sub(start), err)
+				continue
+			}
+			log.Printf("grpc: event %s with aggregate %s version %d action %v finished - duration: %v", e.Topic, e.AggregateID, e.Version, i, time.Now().Sub(start))
+		}
+	}
+}
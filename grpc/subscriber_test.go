@@ -0,0 +1,88 @@
+package grpc_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aukbit/hippo"
+	hgrpc "github.com/aukbit/hippo/grpc"
+	"github.com/aukbit/hippo/mock"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+)
+
+// Ensure historical and live events are streamed from the remote store.
+func TestStoreService_Subscribe(t *testing.T) {
+	var ss mock.StoreService
+	var es mock.EventService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	id := rand.String(10)
+	start := time.Now().UTC()
+	ev1 := hippo.NewEventProto("user_created", id, &pb.User{Name: "Rey"})
+	ev1.Version = 1
+	ev2 := hippo.NewEventProto("user_created", rand.String(10), &pb.User{Name: "Finn"})
+	ev2.Version = 1
+	ev3 := hippo.NewEventProto("order_created", id, &pb.User{Name: "Rey"})
+	ev3.Version = 2
+
+	// Mock EventService.Stream(), events created before subscribing.
+	es.StreamFn = func(ctx context.Context, p hippo.StreamParams) ([]*hippo.Event, error) {
+		var out []*hippo.Event
+		for _, e := range []*hippo.Event{ev1, ev2, ev3} {
+			if e.CreateTime.After(p.After) {
+				out = append(out, e)
+			}
+		}
+		return out, nil
+	}
+
+	// Mock EventService.Create()
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		return nil
+	}
+
+	s, close := MustConnect(t, &ss)
+	defer close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var versions []int64
+	received := make(chan struct{}, 2)
+	collect := func(ctx context.Context, e *hippo.Event) error {
+		mu.Lock()
+		versions = append(versions, e.Version)
+		mu.Unlock()
+		received <- struct{}{}
+		return nil
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- s.Subscribe(ctx, hgrpc.SubscribeParams{AggregateID: id, After: start.Add(-time.Second)}, hippo.ActionTopics{"user_*": []hippo.ActionFn{collect}})
+	}()
+
+	// Historical event.
+	<-received
+
+	// Live event.
+	ev4 := hippo.NewEventProto("user_updated", id, &pb.User{Name: "Rey"})
+	ev4.Version = 3
+	if err := s.EventService().Create(ctx, ev4); err != nil {
+		t.Fatal(err)
+	}
+	<-received
+
+	cancel()
+	assert.Equal(t, nil, <-done)
+	assert.Equal(t, []int64{1, 3}, versions)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 )
@@ -20,6 +21,26 @@ type CacheService interface {
 	DB() interface{}
 }
 
+// CacheDeleter is implemented by a CacheService that invalidates cached aggregates.
+// Stale aggregates are only removed from caches that implement it.
+type CacheDeleter interface {
+	// Delete invalidates the cached aggregate of the given domain type.
+	Delete(ctx context.Context, aggregateID string, domainType interface{}) error
+}
+
+// CachePolicy defines how Dispatch handles cache failures once the event is persisted.
+type CachePolicy int
+
+const (
+	// CacheBestEffort logs cache failures and invalidates the cached aggregate,
+	// Dispatch still returns the aggregate without error.
+	CacheBestEffort CachePolicy = 0
+	// CacheRequired also invalidates the cached aggregate but Dispatch returns
+	// the aggregate together with the cache error. The event is persisted and
+	// published regardless.
+	CacheRequired CachePolicy = 1
+)
+
 // Message represents ID and event to be dispatched
 type Message struct {
 	ID    string
@@ -30,10 +51,22 @@ type Message struct {
 type Params struct {
 	// ID is required
 	ID string
-	// FromVersion (optional)
+	// FromVersion (optional) only events from this version on are returned
 	FromVersion int64
-	// ToVersion (optional)
+	// ToVersion (optional) only events up to this version are returned
 	ToVersion int64
+	// OriginName (optional) only events from this origin are returned
+	OriginName string
+	// OriginIP (optional) only events from this ip address are returned
+	OriginIP string
+}
+
+// StreamParams represents parameters to read events across aggregates
+type StreamParams struct {
+	// After (optional) only events created after this time are returned
+	After time.Time
+	// Limit (optional) maximum number of events returned
+	Limit int
 }
 
 // HookFn represents a function type that will be called after the store is loaded.
@@ -55,12 +88,19 @@ type Aggregate struct {
 	id      string
 	State   interface{}
 	Version int64
+	// signature of the last event, unknown if fetched from cache
+	signature string
 }
 
-// load takes a list of events and apply them to the aggregate
-func (a *Aggregate) load(events []*Event, buffer interface{}, fn DomainTypeRulesFn) error {
+// load takes a list of events, upcasts them to the current schema versions and
+// apply them to the aggregate
+func (a *Aggregate) load(events []*Event, buffer interface{}, fn DomainTypeRulesFn, up *Upcasters) error {
 
 	for _, e := range events {
+		e, err := up.Upcast(e)
+		if err != nil {
+			return err
+		}
 		if err := a.apply(e, buffer, fn); err != nil {
 			return err
 		}
@@ -84,6 +124,7 @@ func (a *Aggregate) apply(e *Event, buffer interface{}, fn DomainTypeRulesFn) er
 
 	// set state version the same as the aggregator
 	a.Version = e.Version
+	a.signature = e.Signature
 	return nil
 }
 
@@ -110,6 +151,13 @@ func clone(format Format, data interface{}) interface{} {
 type Client struct {
 	store         StoreService
 	cache         CacheService
+	outbox        OutboxService
+	publisher     Publisher
+	mode          ConcurrencyMode
+	cachePolicy   CachePolicy
+	signer        *Signer
+	heads         HeadService
+	upcasters     *Upcasters
 	rulesRegistry map[string]DomainTypeRulesFn // a map from domain type names to map functions
 }
 
@@ -117,6 +165,7 @@ type Client struct {
 func NewClient(s StoreService) *Client {
 	return &Client{
 		store:         s,
+		publisher:     Bus{},
 		rulesRegistry: make(DomainTypeRulesMap),
 	}
 }
@@ -152,6 +201,41 @@ func (c *Client) CacheService() CacheService {
 	return c.cache
 }
 
+// RegisterPublisher assigns the publisher used to deliver dispatched events,
+// replacing the in-process Bus. Use MultiPublisher to keep both.
+func (c *Client) RegisterPublisher(p Publisher) {
+	c.publisher = p
+}
+
+// Publisher returns the publisher assigned to the client store
+func (c *Client) Publisher() Publisher {
+	return c.publisher
+}
+
+// SetCachePolicy defines how Dispatch handles cache failures, defaults to CacheBestEffort.
+func (c *Client) SetCachePolicy(policy CachePolicy) {
+	c.cachePolicy = policy
+}
+
+// SetConcurrencyMode defines how Dispatch guarantees that events are appended
+// to the latest version of an aggregate, defaults to OptimisticConcurrency.
+// AtomicAppend only applies to event stores that implement AtomicService and
+// report it, otherwise Dispatch keeps on checking versions optimistically.
+func (c *Client) SetConcurrencyMode(mode ConcurrencyMode) {
+	c.mode = mode
+}
+
+// RegisterOutboxService assigns an outbox service to the client store. The store
+// must record every created event as unpublished in the outbox.
+func (c *Client) RegisterOutboxService(outbox OutboxService) {
+	c.outbox = outbox
+}
+
+// OutboxService returns the outbox service assigned to the client store
+func (c *Client) OutboxService() OutboxService {
+	return c.outbox
+}
+
 // Dispatch returns an aggregate resource based on the event and domain rules defined
 func (c *Client) Dispatch(ctx context.Context, event *Event, buffer interface{}, hooks ...HookFn) (*Aggregate, error) {
 
@@ -172,8 +256,11 @@ func (c *Client) Dispatch(ctx context.Context, event *Event, buffer interface{},
 		return nil, err
 	}
 
-	// Fetch aggregate.
-	agg, err := c.Fetch(ctx, event.AggregateID, tmp)
+	// Fetch aggregate, in atomic append mode a cached aggregate is
+	// trusted and the event store must reject a stale version.
+	atomic := c.atomicAppend()
+	opts := FetchOptions{SkipOptimisticConcurrency: atomic}
+	agg, err := c.FetchWithOptions(ctx, event.AggregateID, tmp, opts)
 	if err != nil && err != ErrAggregateIDWithoutEvents && err != ErrEmptyState {
 		return nil, err
 	}
@@ -189,36 +276,134 @@ func (c *Client) Dispatch(ctx context.Context, event *Event, buffer interface{},
 		return nil, err
 	}
 
+	// New events are at the current schema version, so that they are not upcasted
+	if v := c.upcasters.Version(event.Schema, event.Topic); v > 0 && event.Metadata[SchemaVersionKey] == "" {
+		event.SetSchemaVersion(v)
+	}
+
 	// Increment version by one and assign it to the new event
 	event.SetVersion(agg.Version + 1)
 
+	// Stamp the origin carried by the context, before the event is signed
+	StampOrigin(ctx, event)
+
+	// Sign event chained to the last event of the aggregate
+	if c.signer != nil {
+		previous, err := c.lastSignature(ctx, agg)
+		if err != nil {
+			return nil, err
+		}
+		event.Signature = c.signer.Sign(event, previous)
+	}
+
 	// Persist event to datastore
 	if err := c.store.EventService().Create(ctx, event); err != nil {
+		if err == ErrConcurrencyException && atomic {
+			c.refreshCache(ctx, event, buffer)
+		}
 		return nil, err
 	}
+	c.setHead(ctx, event)
 
 	// Apply last event to the aggregator store
 	if err := agg.apply(event, buffer, c.Rules(buffer)); err != nil {
 		return nil, err
 	}
 
-	// If CacheService is defined store aggregate in cache.
+	// If CacheService is defined store aggregate in cache. The event is already
+	// persisted so a cache failure does not fail the dispatch, unless required.
+	var cacheErr error
 	if c.cache != nil {
 		if err := c.cache.Set(ctx, event.AggregateID, agg); err != nil {
-			return nil, err
+			log.Printf("cache: aggregate %s version %d not cached > error %v", event.AggregateID, agg.Version, err)
+			c.invalidate(ctx, event.AggregateID, buffer)
+			if c.cachePolicy == CacheRequired {
+				cacheErr = err
+			}
 		}
 	}
 
-	// Publish event to subscribers
-	publish(event)
+	// Publish event to subscribers, the event is already persisted so
+	// the relay is responsible for publishing it if publish fails.
+	if err := c.publisher.Publish(ctx, event); err != nil {
+		log.Printf("publisher: event %s with aggregate %s version %d not published > error %v", event.Topic, event.AggregateID, event.Version, err)
+		return agg, cacheErr
+	}
 
-	return agg, nil
+	// If OutboxService is defined mark event as published, otherwise
+	// the relay would publish it again.
+	if c.outbox != nil {
+		if err := c.outbox.MarkPublished(ctx, event); err != nil {
+			log.Printf("outbox: event %s with aggregate %s version %d not marked as published > error %v", event.Topic, event.AggregateID, event.Version, err)
+		}
+	}
+
+	return agg, cacheErr
+}
+
+// invalidate removes the aggregate from cache so that a stale aggregate is never
+// fetched, the next fetch loads it from the event store. Caches that do not
+// implement CacheDeleter keep the stale aggregate.
+func (c *Client) invalidate(ctx context.Context, aggregateID string, domainType interface{}) {
+	d, ok := c.cache.(CacheDeleter)
+	if !ok {
+		log.Printf("cache: aggregate %s not invalidated, cache does not implement delete", aggregateID)
+		return
+	}
+	if err := d.Delete(ctx, aggregateID, domainType); err != nil {
+		log.Printf("cache: aggregate %s not invalidated > error %v", aggregateID, err)
+	}
+}
+
+// refreshCache replaces a stale cached aggregate by the aggregate loaded from the
+// event store, so that a retried dispatch is appended to the latest version.
+func (c *Client) refreshCache(ctx context.Context, event *Event, buffer interface{}) {
+	if c.cache == nil {
+		return
+	}
+	agg, err := c.FetchWithOptions(ctx, event.AggregateID, clone(event.Format, buffer), FetchOptions{SkipCache: true})
+	if err != nil {
+		log.Printf("cache: aggregate %s not refreshed > error %v", event.AggregateID, err)
+		return
+	}
+	if err := c.cache.Set(ctx, event.AggregateID, agg); err != nil {
+		log.Printf("cache: aggregate %s not refreshed > error %v", event.AggregateID, err)
+	}
 }
 
 // FetchOptions is a configurable object for fetch func
 type FetchOptions struct {
+	// SkipOptimisticConcurrency returns a cached aggregate without checking
+	// its version against the last version in the event store.
 	SkipOptimisticConcurrency bool
-	CacheOnly                 bool
+	// CacheOnly returns the aggregate from cache without falling back to the event store.
+	CacheOnly bool
+	// SkipCache loads the aggregate from the event store only.
+	SkipCache bool
+}
+
+// ConcurrencyMode defines how Dispatch guarantees that events are appended
+// to the latest version of an aggregate.
+type ConcurrencyMode int
+
+const (
+	// OptimisticConcurrency checks the version of a cached aggregate against the
+	// last version in the event store before every dispatch.
+	OptimisticConcurrency ConcurrencyMode = 0
+	// AtomicAppend trusts the version of a cached aggregate and relies on the event
+	// store to reject, with ErrConcurrencyException, an event that does not follow
+	// the last version. It requires an event store that implements AtomicService.
+	AtomicAppend ConcurrencyMode = 1
+)
+
+// atomicAppend reports whether Dispatch runs in atomic append mode, which the
+// event store must support.
+func (c *Client) atomicAppend() bool {
+	if c.mode != AtomicAppend {
+		return false
+	}
+	s, ok := c.store.EventService().(AtomicService)
+	return ok && s.Atomic()
 }
 
 func (c *Client) doOptimisticConcurrencyCheck(ctx context.Context, agg *Aggregate) error {
@@ -237,17 +422,31 @@ func (c *Client) doOptimisticConcurrencyCheck(ctx context.Context, agg *Aggregat
 
 // Fetch returns an aggregate resource based on the aggregateID and domain type
 func (c *Client) Fetch(ctx context.Context, aggregateID string, buffer interface{}) (*Aggregate, error) {
+	return c.FetchWithOptions(ctx, aggregateID, buffer, FetchOptions{})
+}
 
-	agg, err := c.FetchFromCache(ctx, aggregateID, buffer)
-	if err == nil {
-		err := c.doOptimisticConcurrencyCheck(ctx, agg)
+// FetchWithOptions returns an aggregate resource based on the aggregateID and domain type
+// and the options on how to use the cache.
+func (c *Client) FetchWithOptions(ctx context.Context, aggregateID string, buffer interface{}, opts FetchOptions) (*Aggregate, error) {
+
+	if !opts.SkipCache {
+		agg, err := c.FetchFromCache(ctx, aggregateID, buffer)
 		if err == nil {
-			return agg, nil
+			if opts.SkipOptimisticConcurrency {
+				return agg, nil
+			}
+			err = c.doOptimisticConcurrencyCheck(ctx, agg)
+			if err == nil {
+				return agg, nil
+			}
+		}
+		if opts.CacheOnly {
+			return nil, err
 		}
 	}
 
 	// Create new aggregate
-	agg = &Aggregate{id: aggregateID}
+	agg := &Aggregate{id: aggregateID}
 
 	// Fetch events from datastore
 	events, err := c.store.EventService().List(ctx, Params{ID: aggregateID})
@@ -260,7 +459,7 @@ func (c *Client) Fetch(ctx context.Context, aggregateID string, buffer interface
 	}
 
 	// Load events into the aggregate
-	if err := agg.load(events, buffer, c.Rules(buffer)); err != nil {
+	if err := agg.load(events, buffer, c.Rules(buffer), c.upcasters); err != nil {
 		return nil, err
 	}
 
@@ -2,8 +2,10 @@ package hippo_test
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/aukbit/hippo"
 	"github.com/aukbit/hippo/mock"
@@ -226,6 +228,207 @@ func TestStore_WithCache(t *testing.T) {
 
 }
 
+func TestStore_FetchWithOptions(t *testing.T) {
+
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+
+	var ss mock.StoreService
+	var es mock.EventService
+	var cs mock.CacheService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	// Mock EventService.GetLastVersion()
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return 2, nil
+	}
+
+	ev1 := hippo.NewEventProto("user_created", user.GetId(), &user)
+	ev1.Version = 1
+
+	// Mock EventService.List()
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		return []*hippo.Event{ev1}, nil
+	}
+
+	// Mock CacheService.Get() call.
+	cs.GetFn = func(ctx context.Context, aggregateID string, out *hippo.Aggregate) error {
+		out.Version = 1
+		out.State = &user
+		return nil
+	}
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(&ss)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	clt.RegisterCacheService(&cs)
+
+	ctx := context.Background()
+
+	// Cache hit without version check.
+	agg, err := clt.FetchWithOptions(ctx, user.GetId(), &pb.User{}, hippo.FetchOptions{SkipOptimisticConcurrency: true})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), agg.Version)
+	assert.Equal(t, true, cs.GetInvoked)
+	assert.Equal(t, false, es.GetLastVersionInvoked)
+	assert.Equal(t, false, es.ListInvoked)
+
+	// Stale cache hit without store fallback.
+	_, err = clt.FetchWithOptions(ctx, user.GetId(), &pb.User{}, hippo.FetchOptions{CacheOnly: true})
+	assert.Equal(t, hippo.ErrConcurrencyException, err)
+	assert.Equal(t, true, es.GetLastVersionInvoked)
+	assert.Equal(t, false, es.ListInvoked)
+
+	// Skip cache.
+	cs.GetInvoked = false
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return 1, nil
+	}
+	agg, err = clt.FetchWithOptions(ctx, user.GetId(), &pb.User{}, hippo.FetchOptions{SkipCache: true})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), agg.Version)
+	assert.Equal(t, false, cs.GetInvoked)
+	assert.Equal(t, true, es.ListInvoked)
+}
+
+func TestStore_WithAtomicAppend(t *testing.T) {
+
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+	ev1 := hippo.NewEventProto("user_created", user.GetId(), &user)
+	ev1.Version = 1
+	ev2 := hippo.NewEventProto("user_updated", user.GetId(), &user)
+	ev2.Version = 2
+
+	var ss mock.StoreService
+	var es mock.EventService
+	var cs mock.CacheService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	// Mock EventService.GetLastVersion()
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return 2, nil
+	}
+
+	// Mock EventService.List()
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		return []*hippo.Event{ev1, ev2}, nil
+	}
+
+	// Mock EventService.Atomic(), the event store checks versions atomically.
+	es.AtomicFn = func() bool {
+		return true
+	}
+
+	// Mock EventService.Create(), version 2 was already appended by another client.
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		return hippo.ErrConcurrencyException
+	}
+
+	// Mock CacheService.Get() call, the cached aggregate is stale.
+	cs.GetFn = func(ctx context.Context, aggregateID string, out *hippo.Aggregate) error {
+		out.Version = 1
+		out.State = &pb.User{Id: user.GetId()}
+		return nil
+	}
+
+	var cached *hippo.Aggregate
+	cs.SetFn = func(ctx context.Context, aggregateID string, in *hippo.Aggregate) error {
+		cached = in
+		return nil
+	}
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(&ss)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	clt.RegisterCacheService(&cs)
+	clt.SetConcurrencyMode(hippo.AtomicAppend)
+
+	ctx := context.Background()
+
+	ev := hippo.NewEventProto("user_updated", user.GetId(), &user)
+	_, err := clt.Dispatch(ctx, ev, &pb.User{})
+	assert.Equal(t, hippo.ErrConcurrencyException, err)
+	assert.Equal(t, true, es.CreateInvoked)
+	assert.Equal(t, true, es.ListInvoked)
+	assert.Equal(t, true, cs.SetInvoked)
+	assert.Equal(t, int64(2), cached.Version)
+}
+
+// Ensure Dispatch checks versions optimistically in atomic append mode when the
+// event store does not check them atomically.
+func TestStore_WithAtomicAppendNotSupported(t *testing.T) {
+	user := pb.User{Id: rand.String(10), Name: "test"}
+	ev1 := hippo.NewEventProto("user_created", user.GetId(), &user)
+	ev1.Version = 1
+
+	var ss mock.StoreService
+	var es mock.EventService
+	var cs mock.CacheService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+	es.AtomicFn = func() bool {
+		return false
+	}
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return 2, nil
+	}
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		return []*hippo.Event{ev1}, nil
+	}
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		return nil
+	}
+
+	// Mock CacheService.Get() call, the cached aggregate is stale.
+	cs.GetFn = func(ctx context.Context, aggregateID string, out *hippo.Aggregate) error {
+		out.Version = 1
+		out.State = &pb.User{Id: user.GetId()}
+		return nil
+	}
+
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(&ss)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	clt.RegisterCacheService(&cs)
+	clt.SetConcurrencyMode(hippo.AtomicAppend)
+
+	ev := hippo.NewEventProto("user_updated", user.GetId(), &user)
+	_, err := clt.Dispatch(context.Background(), ev, &pb.User{})
+	assert.Equal(t, hippo.ErrConcurrencyException, err)
+	assert.Equal(t, true, es.AtomicInvoked)
+	assert.Equal(t, true, es.GetLastVersionInvoked)
+	assert.Equal(t, false, es.CreateInvoked)
+}
+
 func TestStore_WithSubscribers(t *testing.T) {
 	wg := &sync.WaitGroup{}
 	user := pb.User{
@@ -302,3 +505,240 @@ func TestStore_WithSubscribers(t *testing.T) {
 	wg.Wait()
 	hippo.Unsubscribe(c1)
 }
+
+func TestStore_WithOutbox(t *testing.T) {
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "Luke",
+		Email: "luke@email.com",
+	}
+
+	// Create new event for user_created topic.
+	ev1 := hippo.NewEventProto("user_created", user.GetId(), &user)
+
+	var ss mock.StoreService
+	var es mock.EventService
+	var ob mock.OutboxService
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	// Mock EventService.List()
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		return []*hippo.Event{}, nil
+	}
+
+	// Mock EventService.GetLastVersion()
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return 0, nil
+	}
+
+	// Mock EventService.Create()
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		return nil
+	}
+
+	// Mock OutboxService.Pending() with the event left unpublished.
+	pending := []*hippo.Event{ev1}
+	ob.PendingFn = func(ctx context.Context, limit int) ([]*hippo.Event, error) {
+		return pending, nil
+	}
+
+	// Mock OutboxService.MarkPublished()
+	ob.MarkPublishedFn = func(ctx context.Context, e *hippo.Event) error {
+		pending = []*hippo.Event{}
+		return nil
+	}
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(&ss)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	clt.RegisterOutboxService(&ob)
+
+	ctx := context.Background()
+
+	// Create event 1 in store.
+	if _, err := clt.Dispatch(ctx, ev1, &pb.User{}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, true, ob.MarkPublishedInvoked)
+
+	// Relay pending event to subscribers.
+	pending = []*hippo.Event{ev1}
+	c1 := make(chan *hippo.Event, 1)
+	hippo.Subscribe(c1, hippo.ActionTopics{ev1.GetTopic(): []hippo.ActionFn{}})
+	defer hippo.Unsubscribe(c1)
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clt.Relay(ctx, hippo.RelayOptions{Interval: time.Millisecond, Delay: time.Nanosecond})
+	}()
+
+	select {
+	case e := <-c1:
+		assert.Equal(t, ev1, e)
+	case <-ctx.Done():
+		t.Fatal("event not relayed")
+	}
+	// Wait for the relay to stop before reading the mocks.
+	cancel()
+	<-done
+	assert.Equal(t, true, ob.PendingInvoked)
+}
+
+func TestStore_WithPublisher(t *testing.T) {
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "Luke",
+		Email: "luke@email.com",
+	}
+
+	// Create new event for user_created topic.
+	ev1 := hippo.NewEventProto("user_created", user.GetId(), &user)
+
+	var ss mock.StoreService
+	var es mock.EventService
+	var ps mock.Publisher
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	// Mock EventService.List()
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		return []*hippo.Event{}, nil
+	}
+
+	// Mock EventService.GetLastVersion()
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return 0, nil
+	}
+
+	// Mock EventService.Create()
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		return nil
+	}
+
+	// Mock Publisher.Publish()
+	var published []*hippo.Event
+	ps.PublishFn = func(ctx context.Context, e *hippo.Event) error {
+		published = append(published, e)
+		return nil
+	}
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(&ss)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	clt.RegisterPublisher(hippo.MultiPublisher(hippo.Bus{}, &ps))
+
+	// Subscribe to the in-process bus.
+	c1 := make(chan *hippo.Event, 1)
+	hippo.Subscribe(c1, hippo.ActionTopics{ev1.GetTopic(): []hippo.ActionFn{}})
+	defer hippo.Unsubscribe(c1)
+
+	ctx := context.Background()
+
+	// Create event 1 in store.
+	if _, err := clt.Dispatch(ctx, ev1, &pb.User{}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, true, ps.PublishInvoked)
+	assert.Equal(t, []*hippo.Event{ev1}, published)
+	assert.Equal(t, ev1, <-c1)
+}
+
+func TestStore_WithCacheFailure(t *testing.T) {
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "Leia",
+		Email: "leia@email.com",
+	}
+
+	var ss mock.StoreService
+	var es mock.EventService
+	var cs mock.CacheService
+	var ps mock.Publisher
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+
+	// Mock EventService.List()
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		return []*hippo.Event{}, nil
+	}
+
+	// Mock EventService.GetLastVersion()
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return 0, nil
+	}
+
+	// Mock EventService.Create()
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		return nil
+	}
+
+	// Mock CacheService, every write fails.
+	errCache := errors.New("cache unavailable")
+	cs.GetFn = func(ctx context.Context, aggregateID string, out *hippo.Aggregate) error {
+		return hippo.ErrKeyDoesNotExist
+	}
+	cs.SetFn = func(ctx context.Context, aggregateID string, in *hippo.Aggregate) error {
+		return errCache
+	}
+	var deleted []string
+	cs.DeleteFn = func(ctx context.Context, aggregateID string, domainType interface{}) error {
+		deleted = append(deleted, aggregateID)
+		return nil
+	}
+
+	// Mock Publisher.Publish()
+	ps.PublishFn = func(ctx context.Context, e *hippo.Event) error {
+		return nil
+	}
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(&ss)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	clt.RegisterCacheService(&cs)
+	clt.RegisterPublisher(&ps)
+
+	ctx := context.Background()
+
+	// Best effort, the aggregate is returned and the event published.
+	ev1 := hippo.NewEventProto("user_created", user.GetId(), &user)
+	agg, err := clt.Dispatch(ctx, ev1, &pb.User{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), agg.Version)
+	assert.Equal(t, true, ps.PublishInvoked)
+	assert.Equal(t, []string{user.GetId()}, deleted)
+
+	// Required, the cache error is returned together with the aggregate.
+	clt.SetCachePolicy(hippo.CacheRequired)
+	ps.PublishInvoked = false
+	ev2 := hippo.NewEventProto("user_created", user.GetId(), &user)
+	agg, err = clt.Dispatch(ctx, ev2, &pb.User{})
+	assert.Equal(t, errCache, err)
+	assert.Equal(t, int64(1), agg.Version)
+	assert.Equal(t, true, ps.PublishInvoked)
+	assert.Equal(t, []string{user.GetId(), user.GetId()}, deleted)
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/aukbit/hippo"
+)
+
+// formats maps the event formats to their JSON names.
+var formats = map[hippo.Format]string{
+	hippo.PROTOBUF: "protobuf",
+	hippo.JSON:     "json",
+	hippo.STRING:   "string",
+}
+
+// event represents the JSON representation of an event. Data is rendered as
+// JSON for the json format, as a string for the string format and as base64
+// encoded bytes for the protobuf format.
+type event struct {
+	Topic       string            `json:"topic"`
+	AggregateID string            `json:"aggregate_id,omitempty"`
+	Version     int64             `json:"version,omitempty"`
+	Schema      string            `json:"schema,omitempty"`
+	Format      string            `json:"format"`
+	Data        json.RawMessage   `json:"data,omitempty"`
+	Priority    int32             `json:"priority,omitempty"`
+	Signature   string            `json:"signature,omitempty"`
+	OriginName  string            `json:"origin_name,omitempty"`
+	OriginIP    string            `json:"origin_ip,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreateTime  *time.Time        `json:"create_time,omitempty"`
+}
+
+// encode returns the JSON representation of the event.
+func encode(e *hippo.Event) (*event, error) {
+	out := &event{
+		Topic:       e.Topic,
+		AggregateID: e.AggregateID,
+		Version:     e.Version,
+		Schema:      e.Schema,
+		Format:      formats[e.Format],
+		Priority:    e.Priority,
+		Signature:   e.Signature,
+		OriginName:  e.OriginName,
+		OriginIP:    e.OriginIP,
+		Metadata:    e.Metadata,
+		CreateTime:  &e.CreateTime,
+	}
+	if len(e.Data) == 0 {
+		return out, nil
+	}
+	switch e.Format {
+	case hippo.JSON:
+		if !json.Valid(e.Data) {
+			return nil, hippo.ErrInvalidEventFormat
+		}
+		out.Data = e.Data
+	case hippo.STRING:
+		data, err := json.Marshal(string(e.Data))
+		if err != nil {
+			return nil, err
+		}
+		out.Data = data
+	default:
+		data, err := json.Marshal(e.Data)
+		if err != nil {
+			return nil, err
+		}
+		out.Data = data
+	}
+	return out, nil
+}
+
+// decode returns the event of the JSON representation. Version and create
+// time are assigned by the event store, so they are ignored, and so is the
+// signature, which would not cover them.
+func decode(in *event, aggregateID string) (*hippo.Event, error) {
+	e := hippo.NewEventWithMetadata(in.Topic, aggregateID, in.Metadata)
+	e.Schema = in.Schema
+	e.Priority = in.Priority
+	e.OriginName = in.OriginName
+	e.OriginIP = in.OriginIP
+
+	format, ok := format(in.Format)
+	if !ok {
+		return nil, hippo.ErrFormatNotProvided
+	}
+	e.Format = format
+	if len(in.Data) == 0 {
+		return e, nil
+	}
+	switch format {
+	case hippo.JSON:
+		if !json.Valid(in.Data) {
+			return nil, hippo.ErrInvalidEventFormat
+		}
+		e.Data = in.Data
+	case hippo.STRING:
+		var s string
+		if err := json.Unmarshal(in.Data, &s); err != nil {
+			return nil, hippo.ErrInvalidEventFormat
+		}
+		e.Data = []byte(s)
+	default:
+		var s string
+		if err := json.Unmarshal(in.Data, &s); err != nil {
+			return nil, hippo.ErrInvalidEventFormat
+		}
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, hippo.ErrInvalidEventFormat
+		}
+		e.Data = data
+	}
+	return e, nil
+}
+
+// format returns the event format of the JSON name.
+func format(name string) (hippo.Format, bool) {
+	for f, n := range formats {
+		if n == name {
+			return f, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,293 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/aukbit/hippo"
+)
+
+// Handler serves a REST API over a hippo.StoreService:
+//
+//	POST /aggregates/{id}/events            appends events with an expected version
+//	GET  /aggregates/{id}/events?from=&to=&origin_name=&origin_ip=
+//	                                        lists the events between versions and of an origin
+//	GET  /aggregates/{id}/version           returns the last version
+//	GET  /events?topic=&aggregate_id=       streams new events as Server-Sent Events
+//
+// Wrap the handler with the Origin middleware, or OriginBehindProxies, to stamp
+// the origin of the requests on the appended events.
+type Handler struct {
+	store     hippo.StoreService
+	publisher hippo.Publisher
+	outbox    hippo.OutboxService
+}
+
+// NewHandler creates a new Handler for the event store.
+func NewHandler(store hippo.StoreService) *Handler {
+	return &Handler{
+		store:     store,
+		publisher: hippo.Bus{},
+	}
+}
+
+// RegisterPublisher assigns the publisher of the appended events, defaults to
+// the in-process bus from where new events are streamed.
+func (h *Handler) RegisterPublisher(p hippo.Publisher) {
+	h.publisher = p
+}
+
+// RegisterOutboxService assigns the outbox of the event store, where the appended
+// events are marked as published once published, otherwise the relay would
+// publish them again.
+func (h *Handler) RegisterOutboxService(outbox hippo.OutboxService) {
+	h.outbox = outbox
+}
+
+// ServeHTTP routes the request.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "events" && r.Method == http.MethodGet:
+		h.handleStream(w, r)
+	case len(parts) == 3 && parts[0] == "aggregates" && parts[2] == "events" && r.Method == http.MethodPost:
+		h.handleAppend(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "aggregates" && parts[2] == "events" && r.Method == http.MethodGet:
+		h.handleList(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "aggregates" && parts[2] == "version" && r.Method == http.MethodGet:
+		h.handleVersion(w, r, parts[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// appendRequest represents the body of an append request.
+type appendRequest struct {
+	// ExpectedVersion is the last version of the aggregate the events are appended to.
+	ExpectedVersion int64    `json:"expected_version"`
+	Events          []*event `json:"events"`
+}
+
+// appendResponse represents the body of an append response.
+type appendResponse struct {
+	AggregateID string `json:"aggregate_id"`
+	Version     int64  `json:"version"`
+}
+
+// handleAppend appends the events to the aggregate if its last version is the
+// expected version, otherwise it responds with a conflict.
+func (h *Handler) handleAppend(w http.ResponseWriter, r *http.Request, aggregateID string) {
+	var req appendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, hippo.ErrInvalidEventFormat)
+		return
+	}
+	events := make([]*hippo.Event, 0, len(req.Events))
+	for _, in := range req.Events {
+		e, err := decode(in, aggregateID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		events = append(events, e)
+	}
+
+	ctx := r.Context()
+	v, err := h.store.EventService().GetLastVersion(ctx, aggregateID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if v != req.ExpectedVersion {
+		writeError(w, hippo.ErrConcurrencyException)
+		return
+	}
+	for _, e := range events {
+		v++
+		e.SetVersion(v)
+		hippo.StampOrigin(ctx, e)
+	}
+
+	// The event store rejects the versions appended by a concurrent request
+	// in between. Several events are appended all at once, which requires
+	// an event store that implements hippo.AppendService.
+	if err := h.append(ctx, events); err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, e := range events {
+		h.publish(ctx, e)
+	}
+	writeJSON(w, http.StatusCreated, &appendResponse{AggregateID: aggregateID, Version: v})
+}
+
+// publish publishes the persisted event to subscribers and marks it as published
+// in the outbox, if any. Without an outbox an event that fails to publish is lost
+// for the subscribers, with an outbox the relay publishes it again.
+func (h *Handler) publish(ctx context.Context, e *hippo.Event) {
+	if err := h.publisher.Publish(ctx, e); err != nil {
+		log.Printf("http: event %s with aggregate %s version %d not published > error %v", e.Topic, e.AggregateID, e.Version, err)
+		return
+	}
+	if h.outbox == nil {
+		return
+	}
+	if err := h.outbox.MarkPublished(ctx, e); err != nil {
+		log.Printf("http: event %s with aggregate %s version %d not marked as published > error %v", e.Topic, e.AggregateID, e.Version, err)
+	}
+}
+
+// append persists the events of an aggregate, either all or none of them.
+func (h *Handler) append(ctx context.Context, events []*hippo.Event) error {
+	if as, ok := h.store.EventService().(hippo.AppendService); ok {
+		return as.Append(ctx, events)
+	}
+	switch len(events) {
+	case 0:
+		return nil
+	case 1:
+		return h.store.EventService().Create(ctx, events[0])
+	}
+	return hippo.ErrNotImplemented
+}
+
+// handleList responds with the events of the aggregate, optionally between versions.
+func (h *Handler) handleList(w http.ResponseWriter, r *http.Request, aggregateID string) {
+	p := hippo.Params{ID: aggregateID}
+	for name, v := range map[string]*int64{"from": &p.FromVersion, "to": &p.ToVersion} {
+		if s := r.URL.Query().Get(name); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, &errorResponse{Err: fmt.Sprintf("invalid %s version", name)})
+				return
+			}
+			*v = n
+		}
+	}
+	p.OriginName = r.URL.Query().Get("origin_name")
+	p.OriginIP = r.URL.Query().Get("origin_ip")
+
+	events, err := h.store.EventService().List(r.Context(), p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out := make([]*event, 0, len(events))
+	for _, e := range events {
+		ev, err := encode(e)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		out = append(out, ev)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleVersion responds with the last version of the aggregate.
+func (h *Handler) handleVersion(w http.ResponseWriter, r *http.Request, aggregateID string) {
+	v, err := h.store.EventService().GetLastVersion(r.Context(), aggregateID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &appendResponse{AggregateID: aggregateID, Version: v})
+}
+
+// handleStream streams the new events of the topic and aggregate as Server-Sent
+// Events until the client disconnects. Topic patterns are supported. A client that
+// falls behind is disconnected instead of blocking the publishers.
+func (h *Handler) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	topic := r.URL.Query().Get("topic")
+	aggregateID := r.URL.Query().Get("aggregate_id")
+
+	// send writes an event to the client.
+	send := func(ctx context.Context, e *hippo.Event) error {
+		ev, err := encode(e)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %s:%d\nevent: %s\ndata: %s\n\n", e.AggregateID, e.Version, e.Topic, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	filter := func(e *hippo.Event) bool {
+		if aggregateID != "" && aggregateID != e.AggregateID {
+			return false
+		}
+		if topic == "" {
+			return true
+		}
+		ok, _ := path.Match(topic, e.Topic)
+		return ok
+	}
+
+	// Subscribe before responding, so that no event is missed once the client
+	// receives the headers.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	c := make(chan *hippo.Event, 100)
+	hippo.SubscribeFilter(c, filter, []hippo.ActionFn{send})
+	hippo.OnOverflow(c, func(e *hippo.Event) { cancel() })
+	defer hippo.Unsubscribe(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	hippo.Worker(ctx, c)
+	if ctx.Err() != nil && r.Context().Err() == nil {
+		log.Printf("http: subscriber %s disconnected, events dropped", r.RemoteAddr)
+	}
+}
+
+// errorResponse represents the body of an error response.
+type errorResponse struct {
+	Err string `json:"error"`
+}
+
+// writeError writes the error with the status code of the hippo error.
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch err {
+	case hippo.ErrConcurrencyException:
+		code = http.StatusConflict
+	case hippo.ErrParamsIDRequired, hippo.ErrAggregateIDCanNotBeEmpty, hippo.ErrFormatNotProvided,
+		hippo.ErrInvalidEventFormat, hippo.ErrInvalidSchema:
+		code = http.StatusBadRequest
+	case hippo.ErrAggregateIDWithoutEvents, hippo.ErrEventNotFound:
+		code = http.StatusNotFound
+	case hippo.ErrNotImplemented:
+		code = http.StatusNotImplemented
+	}
+	writeJSON(w, code, &errorResponse{Err: err.Error()})
+}
+
+// writeJSON writes v as JSON with the status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("http: response not encoded > error %v", err)
+	}
+}
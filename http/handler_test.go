@@ -0,0 +1,305 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	hhttp "github.com/aukbit/hippo/http"
+	"github.com/aukbit/hippo/mock"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+)
+
+// MustOpenStore returns a mock store holding events in memory.
+func MustOpenStore() *mock.StoreService {
+	return mock.NewEvents().StoreService()
+}
+
+func TestHandler_Events(t *testing.T) {
+	srv := httptest.NewServer(hhttp.NewHandler(MustOpenStore()))
+	defer srv.Close()
+
+	id := rand.String(10)
+	url := srv.URL + "/aggregates/" + id
+
+	// Append events.
+	body := `{"expected_version": 0, "events": [
+		{"topic": "user_created", "format": "json", "data": {"name": "Poe"}},
+		{"topic": "user_renamed", "format": "string", "data": "Dameron"},
+		{"topic": "user_updated", "format": "protobuf", "data": "CgNQb2U="}
+	]}`
+	resp, err := http.Post(url+"/events", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// Append with a stale expected version.
+	resp, err = http.Post(url+"/events", "application/json", strings.NewReader(`{"expected_version": 1, "events": [{"topic": "user_deleted", "format": "json"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Last version.
+	resp, err = http.Get(url + "/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var version struct {
+		Version int64 `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, int64(3), version.Version)
+
+	// List events between versions.
+	resp, err = http.Get(url + "/events?from=1&to=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []struct {
+		Topic   string          `json:"topic"`
+		Version int64           `json:"version"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, int64(1), events[0].Version)
+	assert.Equal(t, `{"name":"Poe"}`, string(events[0].Data))
+	assert.Equal(t, `"Dameron"`, string(events[1].Data))
+
+	// List events from a version on.
+	resp, err = http.Get(url + "/events?from=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events = nil
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, int64(2), events[0].Version)
+
+	// Invalid version range.
+	resp, err = http.Get(url + "/events?from=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// Ensure appended events are marked as published in the outbox.
+func TestHandler_Outbox(t *testing.T) {
+	var outbox mock.OutboxService
+	var published []int64
+	outbox.MarkPublishedFn = func(ctx context.Context, e *hippo.Event) error {
+		published = append(published, e.Version)
+		return nil
+	}
+
+	h := hhttp.NewHandler(MustOpenStore())
+	h.RegisterOutboxService(&outbox)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	body := `{"expected_version": 0, "events": [{"topic": "user_created", "format": "json"}, {"topic": "user_updated", "format": "json"}]}`
+	resp, err := http.Post(srv.URL+"/aggregates/"+rand.String(10)+"/events", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []int64{1, 2}, published)
+}
+
+func TestHandler_Stream(t *testing.T) {
+	srv := httptest.NewServer(hhttp.NewHandler(MustOpenStore()))
+	defer srv.Close()
+
+	id := rand.String(10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/events?topic=user_*&aggregate_id="+id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Append events once subscribed.
+	body := `{"expected_version": 0, "events": [
+		{"topic": "order_created", "format": "json", "data": {}},
+		{"topic": "user_created", "format": "json", "data": {"name": "BB-8"}}
+	]}`
+	r, err := http.Post(srv.URL+"/aggregates/"+id+"/events", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && scanner.Text() != "" {
+		lines = append(lines, scanner.Text())
+	}
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "id: "+id+":2", lines[0])
+	assert.Equal(t, "event: user_created", lines[1])
+	assert.Equal(t, true, strings.Contains(lines[2], `"data":{"name":"BB-8"}`))
+}
+
+func TestHandler_Origin(t *testing.T) {
+	origin, err := hhttp.OriginBehindProxies("127.0.0.1", "192.168.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(origin(hhttp.NewHandler(MustOpenStore())))
+	defer srv.Close()
+
+	url := srv.URL + "/aggregates/" + rand.String(10) + "/events"
+
+	// Append events from two services behind trusted proxies, the origin
+	// in the body is overridden.
+	for i, name := range []string{"billing", "shipping"} {
+		body := fmt.Sprintf(`{"expected_version": %d, "events": [{"topic": "user_updated", "format": "json", "origin_name": "admin", "origin_ip": "10.0.0.9"}]}`, i)
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(hhttp.OriginNameHeader, name)
+		req.Header.Set("X-Forwarded-For", "10.0.0.1, 192.168.0.1")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	// List events of one origin.
+	resp, err := http.Get(url + "?origin_name=shipping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []struct {
+		Version    int64  `json:"version"`
+		OriginName string `json:"origin_name"`
+		OriginIP   string `json:"origin_ip"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, int64(2), events[0].Version)
+	assert.Equal(t, "shipping", events[0].OriginName)
+	assert.Equal(t, "10.0.0.1", events[0].OriginIP)
+
+	// Invalid proxies are rejected.
+	_, err = hhttp.OriginBehindProxies("proxy")
+	assert.Equal(t, true, err != nil)
+}
+
+// Ensure forwarding headers are ignored without trusted proxies.
+func TestHandler_OriginUntrustedProxy(t *testing.T) {
+	srv := httptest.NewServer(hhttp.Origin(hhttp.NewHandler(MustOpenStore())))
+	defer srv.Close()
+
+	url := srv.URL + "/aggregates/" + rand.String(10) + "/events"
+	body := `{"expected_version": 0, "events": [{"topic": "user_updated", "format": "json"}]}`
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	req.Header.Set("X-Real-Ip", "10.0.0.1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []struct {
+		OriginIP string `json:"origin_ip"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "127.0.0.1", events[0].OriginIP)
+}
+
+// Ensure concurrent appends with the same expected version append only one batch.
+func TestHandler_ConcurrentAppend(t *testing.T) {
+	srv := httptest.NewServer(hhttp.NewHandler(MustOpenStore()))
+	defer srv.Close()
+
+	url := srv.URL + "/aggregates/" + rand.String(10)
+	body := `{"expected_version": 0, "events": [
+		{"topic": "user_created", "format": "json"},
+		{"topic": "user_updated", "format": "json"}
+	]}`
+
+	var mu sync.Mutex
+	codes := make(map[int]int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post(url+"/events", "application/json", strings.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			mu.Lock()
+			codes[resp.StatusCode]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: 4}, codes)
+
+	resp, err := http.Get(url + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []struct {
+		Version int64 `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, 2, len(events))
+}
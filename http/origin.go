@@ -0,0 +1,104 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/aukbit/hippo"
+)
+
+// OriginNameHeader is the header of the name of the calling service.
+const OriginNameHeader = "X-Hippo-Origin-Name"
+
+// Origin returns a middleware that carries the origin of the request in its
+// context, the calling service from the OriginNameHeader header and the client
+// ip address, so that Dispatch stamps it on the events. The client ip address is
+// the remote address; the X-Forwarded-For and X-Real-Ip headers are ignored, see
+// OriginBehindProxies.
+func Origin(next http.Handler) http.Handler {
+	return origin(next, nil)
+}
+
+// OriginBehindProxies returns an Origin middleware that reads the client ip
+// address from the X-Forwarded-For and X-Real-Ip headers of the requests sent
+// by the trusted proxies, given as ip addresses or CIDR ranges. The client ip
+// address is the last one of X-Forwarded-For not added by a trusted proxy.
+func OriginBehindProxies(proxies ...string) (func(http.Handler) http.Handler, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", p)
+			}
+			if v4 := ip.To4(); v4 != nil {
+				ip = v4
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(8*len(ip), 8*len(ip))})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q: %v", p, err)
+		}
+		nets = append(nets, n)
+	}
+	return func(next http.Handler) http.Handler {
+		return origin(next, nets)
+	}, nil
+}
+
+// origin returns the Origin middleware trusting the forwarding headers of the
+// given proxies.
+func origin(next http.Handler, proxies []*net.IPNet) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o := hippo.Origin{
+			Name: r.Header.Get(OriginNameHeader),
+			IP:   clientIP(r, proxies),
+		}
+		next.ServeHTTP(w, r.WithContext(hippo.NewOriginContext(r.Context(), o)))
+	})
+}
+
+// clientIP returns the ip address of the client of the request. Forwarding
+// headers are only read from the trusted proxies, walking X-Forwarded-For
+// backwards past the addresses of the trusted proxies.
+func clientIP(r *http.Request, proxies []*net.IPNet) string {
+	addr := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if !trusted(addr, proxies) {
+		return addr
+	}
+	if v := r.Header.Get("X-Forwarded-For"); v != "" {
+		hops := strings.Split(v, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr = strings.TrimSpace(hops[i])
+			if !trusted(addr, proxies) {
+				break
+			}
+		}
+		return addr
+	}
+	if v := r.Header.Get("X-Real-Ip"); v != "" {
+		return strings.TrimSpace(v)
+	}
+	return addr
+}
+
+// trusted reports whether the ip address belongs to a trusted proxy.
+func trusted(addr string, proxies []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
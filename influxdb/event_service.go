@@ -5,66 +5,200 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aukbit/hippo"
-	"github.com/aukbit/hippo/influxdb/internal"
+	"github.com/aukbit/hippo/internal"
 )
 
-// Ensure EventService implements hippo.EventService.
+// Ensure EventService implements hippo.EventService, hippo.StreamService,
+// hippo.AppendService and hippo.AtomicService.
 var _ hippo.EventService = &EventService{}
+var _ hippo.StreamService = &EventService{}
+var _ hippo.AppendService = &EventService{}
+var _ hippo.AtomicService = &EventService{}
 
 // EventService represents a service for managing an aggregate store
 // with a InfluxDB client connected.
 type EventService struct {
 	// db client
 	store *StoreService
+
+	// locks by aggregate, so that the version check and the write of an event
+	// are not interleaved with another event of the same aggregate
+	mu    sync.Mutex
+	locks map[string]*aggregateLock
+}
+
+// aggregateLock serializes the writes of an aggregate.
+type aggregateLock struct {
+	sync.Mutex
+	// number of writes holding or waiting for the lock
+	n int
+}
+
+// lock locks the writes of the aggregate and returns the function to unlock them.
+func (s *EventService) lock(aggregateID string) func() {
+	s.mu.Lock()
+	if s.locks == nil {
+		s.locks = make(map[string]*aggregateLock)
+	}
+	l, ok := s.locks[aggregateID]
+	if !ok {
+		l = &aggregateLock{}
+		s.locks[aggregateID] = l
+	}
+	l.n++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		if l.n--; l.n == 0 {
+			delete(s.locks, aggregateID)
+		}
+		s.mu.Unlock()
+	}
 }
 
-// Create persists the event.
+// Create persists the event. An event whose version does not follow the last
+// version of the aggregate is rejected with hippo.ErrConcurrencyException.
+// InfluxDB has no conditional writes, so the version check and the write are
+// serialized by aggregate within this process, i.e. concurrent writers must share
+// the store, e.g. behind the gRPC server.
 func (s *EventService) Create(ctx context.Context, e *hippo.Event) error {
 	start := time.Now()
 
-	// Create a new batch points
-	bp, err := s.store.BatchPoints()
+	unlock := s.lock(e.AggregateID)
+	defer unlock()
+
+	// Versions start at 1, an aggregate without events has version 0.
+	v, err := s.GetLastVersion(ctx, e.AggregateID)
 	if err != nil {
 		return err
 	}
-
-	// Create a point and add to batch
-	tags := map[string]string{
-		"aggregate_id": e.AggregateID,
-		"topic":        e.Topic,
+	if e.Version != v+1 {
+		return hippo.ErrConcurrencyException
 	}
 
-	// Encode event
-	data, err := internal.MarshalEventText(e)
-	if err != nil {
+	if err := s.write([]*hippo.Event{e}); err != nil {
 		return err
 	}
-	fields := map[string]interface{}{
-		"data":    string(data),
-		"version": e.Version,
+
+	log.Printf("event %s with aggregate %s version %d created - duration: %v", e.Topic, e.AggregateID, e.Version, time.Now().Sub(start))
+	return nil
+}
+
+// Append persists the events of an aggregate in a single write, so that either
+// all or none of them are persisted. The versions must follow the last version
+// of the aggregate, otherwise the events are rejected with hippo.ErrConcurrencyException.
+// The version check is serialized by aggregate the same as in Create.
+func (s *EventService) Append(ctx context.Context, events []*hippo.Event) error {
+	if len(events) == 0 {
+		return nil
 	}
-	pt, err := s.store.NewPoint("events", tags, fields, e.CreateTime)
+	start := time.Now()
+	id := events[0].AggregateID
+
+	unlock := s.lock(id)
+	defer unlock()
+
+	v, err := s.GetLastVersion(ctx, id)
 	if err != nil {
 		return err
 	}
-	bp.AddPoint(pt)
+	for _, e := range events {
+		if e.AggregateID != id {
+			return hippo.ErrInvalidEventFormat
+		}
+		if v++; e.Version != v {
+			return hippo.ErrConcurrencyException
+		}
+	}
 
-	// Write the batch
-	if err := s.store.db.Write(bp); err != nil {
+	if err := s.write(events); err != nil {
 		return err
 	}
 
-	log.Printf("event %s with aggregate %s version %d created - duration: %v", e.Topic, e.AggregateID, e.Version, time.Now().Sub(start))
+	log.Printf("%d events with aggregate %s up to version %d appended - duration: %v", len(events), id, v, time.Now().Sub(start))
 	return nil
 }
 
+// Atomic reports whether the version checks of Create and Append hold for all
+// the writers, i.e. the store is configured as the single writer of the database.
+func (s *EventService) Atomic() bool {
+	return s.store.singleWriter
+}
+
+// write writes the events as points of a single batch.
+func (s *EventService) write(events []*hippo.Event) error {
+	// Create a new batch points
+	bp, err := s.store.BatchPoints()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		// Create a point and add to batch
+		tags := eventTags(e)
+
+		// Encode event
+		data, err := internal.MarshalEventText(e)
+		if err != nil {
+			return err
+		}
+		fields := map[string]interface{}{
+			"data":    string(data),
+			"version": e.Version,
+		}
+		// Record event as unpublished in the same write
+		if s.store.outbox {
+			fields["published"] = false
+		}
+		pt, err := s.store.NewPoint("events", tags, fields, e.CreateTime)
+		if err != nil {
+			return err
+		}
+		bp.AddPoint(pt)
+	}
+
+	// Write the batch
+	return s.store.db.Write(bp)
+}
+
+// quoteReplacer escapes the characters of InfluxQL string literals.
+var quoteReplacer = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// quote returns s as an InfluxQL string literal, so that values from clients,
+// e.g. aggregate ids, are never read as part of the query.
+func quote(s string) string {
+	return "'" + quoteReplacer.Replace(s) + "'"
+}
+
+// eventTags returns the tags of the event point. Points are updated in place
+// by writing the same tags, so every write of the event must use them.
+func eventTags(e *hippo.Event) map[string]string {
+	tags := map[string]string{
+		"aggregate_id": e.AggregateID,
+		"topic":        e.Topic,
+	}
+	// Origin tags are only written when set, so that events are filtered by origin
+	if e.OriginName != "" {
+		tags["origin_name"] = e.OriginName
+	}
+	if e.OriginIP != "" {
+		tags["origin_ip"] = e.OriginIP
+	}
+	return tags
+}
+
 // GetLastVersion fetches the last version for the aggregate
 func (s *EventService) GetLastVersion(ctx context.Context, aggregateID string) (int64, error) {
 	start := time.Now()
-	cmd := fmt.Sprintf("select last(version) from events where aggregate_id='%s'", aggregateID)
+	cmd := fmt.Sprintf("select last(version) from events where aggregate_id=%s", quote(aggregateID))
 	response, err := s.store.db.Query(s.store.Query(cmd))
 	if err != nil {
 		return 0, err
@@ -95,12 +229,20 @@ func (s *EventService) List(ctx context.Context, params hippo.Params) ([]*hippo.
 		return nil, hippo.ErrParamsIDRequired
 	}
 
-	var cmd string
-	// Load all events between versions
-	if params.FromVersion >= 0 && params.ToVersion > 0 {
-		cmd = fmt.Sprintf("select data from events where aggregate_id='%s' and version>=%d and version <=%d", params.ID, params.FromVersion, params.ToVersion)
-	} else {
-		cmd = fmt.Sprintf("select data from events where aggregate_id='%s'", params.ID)
+	cmd := fmt.Sprintf("select data from events where aggregate_id=%s", quote(params.ID))
+	// Load the events between versions, either bound is optional
+	if params.FromVersion > 0 {
+		cmd = fmt.Sprintf("%s and version>=%d", cmd, params.FromVersion)
+	}
+	if params.ToVersion > 0 {
+		cmd = fmt.Sprintf("%s and version<=%d", cmd, params.ToVersion)
+	}
+	// Filter events by origin
+	if params.OriginName != "" {
+		cmd = fmt.Sprintf("%s and origin_name=%s", cmd, quote(params.OriginName))
+	}
+	if params.OriginIP != "" {
+		cmd = fmt.Sprintf("%s and origin_ip=%s", cmd, quote(params.OriginIP))
 	}
 	response, err := s.store.db.Query(s.store.Query(cmd))
 	if err != nil {
@@ -126,3 +268,38 @@ func (s *EventService) List(ctx context.Context, params hippo.Params) ([]*hippo.
 	log.Printf("%s --> no events to fetch - duration: %v", cmd, time.Now().Sub(start))
 	return events, nil
 }
+
+// Stream fetches events across all aggregates ordered by create time
+func (s *EventService) Stream(ctx context.Context, params hippo.StreamParams) ([]*hippo.Event, error) {
+	start := time.Now()
+	var events []*hippo.Event
+
+	cmd := "select data from events"
+	if !params.After.IsZero() {
+		cmd = fmt.Sprintf("%s where time > '%s'", cmd, params.After.UTC().Format(time.RFC3339Nano))
+	}
+	if params.Limit > 0 {
+		cmd = fmt.Sprintf("%s limit %d", cmd, params.Limit)
+	}
+	response, err := s.store.db.Query(s.store.Query(cmd))
+	if err != nil {
+		return nil, err
+	}
+	if response.Error() != nil {
+		return nil, response.Error()
+	}
+	for _, res := range response.Results {
+		for _, ser := range res.Series {
+			for _, val := range ser.Values {
+				e := &hippo.Event{}
+				if err := internal.UnmarshalEventText(val[1].(string), e); err != nil {
+					return nil, err
+				}
+				events = append(events, e)
+			}
+		}
+	}
+
+	log.Printf("%s --> %d events fetched - duration: %v", cmd, len(events), time.Now().Sub(start))
+	return events, nil
+}
@@ -3,6 +3,7 @@ package influxdb_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/aukbit/hippo"
 	pb "github.com/aukbit/hippo/test/proto"
@@ -22,6 +23,7 @@ func TestEventService_Create(t *testing.T) {
 
 	// Create new event for user_created topic.
 	event := hippo.NewEvent("user_created", user.GetId())
+	event.Version = 1
 	// Marshal user proto and assign it to event data
 	if err := event.MarshalProto(&user); err != nil {
 		t.Fatal(err)
@@ -48,6 +50,7 @@ func TestEventService_GetLastVersion(t *testing.T) {
 
 	// Create new event for user_created topic.
 	event := hippo.NewEvent("user_created", user.GetId())
+	event.Version = 1
 	// Marshal user proto and assign it to event data
 	if err := event.MarshalProto(&user); err != nil {
 		t.Fatal(err)
@@ -63,8 +66,8 @@ func TestEventService_GetLastVersion(t *testing.T) {
 	// Get last event version from store.
 	if n, err := c.EventService().GetLastVersion(ctx, user.GetId()); err != nil {
 		t.Fatal(err)
-	} else if n != 0 {
-		t.Fatalf("unexpected version: %#v != 0", n)
+	} else if n != 1 {
+		t.Fatalf("unexpected version: %#v != 1", n)
 	}
 
 }
@@ -81,6 +84,7 @@ func TestEventService_ListEvents(t *testing.T) {
 
 	// Create new event for user_created topic.
 	ev1 := hippo.NewEvent("user_created", user.GetId())
+	ev1.Version = 1
 	// Marshal user proto and assign it to event data
 	if err := ev1.MarshalProto(&user); err != nil {
 		t.Fatal(err)
@@ -102,7 +106,7 @@ func TestEventService_ListEvents(t *testing.T) {
 		t.Fatal(err)
 	}
 	// Increase event aggregate version to avoid concurrency exception
-	ev2.Version = 1
+	ev2.Version = 2
 
 	// Create event 2 in store.
 	if err := c.EventService().Create(ctx, ev2); err != nil {
@@ -120,4 +124,163 @@ func TestEventService_ListEvents(t *testing.T) {
 		t.Fatalf("unexpected number of events: %#v != 2", len(events))
 	}
 
+	// List events from a version on
+	p.FromVersion = 2
+	if events, err := c.EventService().List(ctx, p); err != nil {
+		t.Fatal(err)
+	} else if len(events) != 1 {
+		t.Fatalf("unexpected number of events: %#v != 1", len(events))
+	}
+
+}
+
+// Ensure an event whose version does not follow the last version is rejected.
+func TestEventService_CreateConcurrencyException(t *testing.T) {
+	c := MustConnectStore()
+	defer c.Close()
+
+	ctx := context.Background()
+	id := rand.String(10)
+
+	e := hippo.NewEvent("user_created", id)
+	e.Version = 1
+	if err := c.EventService().Create(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create events with the same version, a gap or no version.
+	for _, v := range []int64{1, 3, 0} {
+		e = hippo.NewEvent("user_updated", id)
+		e.Version = v
+		if err := c.EventService().Create(ctx, e); err != hippo.ErrConcurrencyException {
+			t.Fatalf("unexpected error for version %d: %v", v, err)
+		}
+	}
+
+	// Versions of a new aggregate start at 1.
+	e = hippo.NewEvent("user_created", rand.String(10))
+	if err := c.EventService().Create(ctx, e); err != hippo.ErrConcurrencyException {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure events can be appended all at once and aggregate ids are quoted.
+func TestEventService_Append(t *testing.T) {
+	c := MustConnectStore()
+	defer c.Close()
+
+	ctx := context.Background()
+	id := rand.String(10)
+	es := c.EventService().(hippo.AppendService)
+
+	var events []*hippo.Event
+	for i := 1; i <= 2; i++ {
+		e := hippo.NewEvent("user_updated", id)
+		e.Version = int64(i)
+		events = append(events, e)
+	}
+	if err := es.Append(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+
+	// Appending from a stale version is rejected without any event persisted.
+	e := hippo.NewEvent("user_updated", id)
+	e.Version = 2
+	if err := es.Append(ctx, []*hippo.Event{e}); err != hippo.ErrConcurrencyException {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, err := c.EventService().GetLastVersion(ctx, id); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("unexpected version: %d != 2", n)
+	}
+
+	// An id crafted to match other aggregates matches no events.
+	if events, err := c.EventService().List(ctx, hippo.Params{ID: `x' or aggregate_id=~/.*/ or aggregate_id='`}); err != nil {
+		t.Fatal(err)
+	} else if len(events) != 0 {
+		t.Fatalf("unexpected number of events: %#v != 0", len(events))
+	}
+}
+
+// Ensure events can be filtered by origin.
+func TestEventService_ListEventsByOrigin(t *testing.T) {
+	c := MustConnectStore()
+	defer c.Close()
+
+	ctx := context.Background()
+	id := rand.String(10)
+
+	// Create events from two origins.
+	for i, name := range []string{"billing", "shipping"} {
+		e := hippo.NewEvent("user_updated", id)
+		e.Version = int64(i + 1)
+		e.OriginName = name
+		e.OriginIP = "10.0.0.1"
+		if err := c.EventService().Create(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// List events of one origin
+	if events, err := c.EventService().List(ctx, hippo.Params{ID: id, OriginName: "billing"}); err != nil {
+		t.Fatal(err)
+	} else if len(events) != 1 {
+		t.Fatalf("unexpected number of events: %#v != 1", len(events))
+	} else if events[0].OriginName != "billing" {
+		t.Fatalf("unexpected origin: %s != billing", events[0].OriginName)
+	}
+
+	// List events of an ip address
+	if events, err := c.EventService().List(ctx, hippo.Params{ID: id, OriginIP: "10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	} else if len(events) != 2 {
+		t.Fatalf("unexpected number of events: %#v != 2", len(events))
+	}
+
+	// Quotes in the origin are escaped, not part of the query
+	if events, err := c.EventService().List(ctx, hippo.Params{ID: id, OriginName: `billing' or origin_name='shipping`}); err != nil {
+		t.Fatal(err)
+	} else if len(events) != 0 {
+		t.Fatalf("unexpected number of events: %#v != 0", len(events))
+	}
+}
+
+func TestEventService_Stream(t *testing.T) {
+	c := MustConnectStore()
+	defer c.Close()
+
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+
+	// Create new event for user_created topic.
+	event := hippo.NewEvent("user_created", user.GetId())
+	event.Version = 1
+	// Marshal user proto and assign it to event data
+	if err := event.MarshalProto(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	after := event.CreateTime.Add(-time.Nanosecond)
+
+	// Create event in store.
+	if err := c.EventService().Create(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stream events created after the event.
+	p := hippo.StreamParams{
+		After: after,
+	}
+	if events, err := c.EventService().(hippo.StreamService).Stream(ctx, p); err != nil {
+		t.Fatal(err)
+	} else if len(events) == 0 {
+		t.Fatal("unexpected number of events: 0")
+	} else if events[0].AggregateID != user.GetId() {
+		t.Fatalf("unexpected aggregate: %#v != %#v", events[0].AggregateID, user.GetId())
+	}
 }
@@ -0,0 +1,80 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+)
+
+// Ensure OutboxService implements hippo.OutboxService.
+var _ hippo.OutboxService = &OutboxService{}
+
+// OutboxService represents a service for managing unpublished events
+// with a InfluxDB client connected.
+type OutboxService struct {
+	// db client
+	store *StoreService
+}
+
+// Pending fetches events not yet published ordered by create time
+func (s *OutboxService) Pending(ctx context.Context, limit int) ([]*hippo.Event, error) {
+	start := time.Now()
+	var events []*hippo.Event
+
+	cmd := "select data from events where published=false"
+	if limit > 0 {
+		cmd = fmt.Sprintf("%s limit %d", cmd, limit)
+	}
+	response, err := s.store.db.Query(s.store.Query(cmd))
+	if err != nil {
+		return nil, err
+	}
+	if response.Error() != nil {
+		return nil, response.Error()
+	}
+	for _, res := range response.Results {
+		for _, ser := range res.Series {
+			for _, val := range ser.Values {
+				e := &hippo.Event{}
+				if err := internal.UnmarshalEventText(val[1].(string), e); err != nil {
+					return nil, err
+				}
+				events = append(events, e)
+			}
+		}
+	}
+
+	log.Printf("%s --> %d events fetched - duration: %v", cmd, len(events), time.Now().Sub(start))
+	return events, nil
+}
+
+// MarkPublished flags the event as published. InfluxDB merges the fields of
+// points with the same series and timestamp, so the event point is updated in place.
+func (s *OutboxService) MarkPublished(ctx context.Context, e *hippo.Event) error {
+	start := time.Now()
+
+	bp, err := s.store.BatchPoints()
+	if err != nil {
+		return err
+	}
+	tags := eventTags(e)
+	fields := map[string]interface{}{
+		"published": true,
+	}
+	pt, err := s.store.NewPoint("events", tags, fields, e.CreateTime)
+	if err != nil {
+		return err
+	}
+	bp.AddPoint(pt)
+
+	if err := s.store.db.Write(bp); err != nil {
+		return err
+	}
+
+	log.Printf("event %s with aggregate %s version %d marked as published - duration: %v", e.Topic, e.AggregateID, e.Version, time.Now().Sub(start))
+	return nil
+}
@@ -0,0 +1,68 @@
+package influxdb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/influxdb"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+)
+
+// Ensure created events are pending until marked as published.
+func TestOutboxService_MarkPublished(t *testing.T) {
+	c := NewStore()
+	if err := c.Connect(influxdb.Config{
+		Database: "hippo_db_test",
+		Outbox:   true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+
+	// Create new event for user_created topic, the origin is written as tags.
+	event := hippo.NewEventProto("user_created", user.GetId(), &user)
+	event.Version = 1
+	event.OriginName = "billing"
+	event.OriginIP = "10.0.0.1"
+
+	ctx := context.Background()
+
+	// Create event in store.
+	if err := c.EventService().Create(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := func() bool {
+		events, err := c.OutboxService().Pending(ctx, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range events {
+			if e.AggregateID == user.GetId() {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !pending() {
+		t.Fatal("expected event to be pending")
+	}
+
+	// Mark event as published.
+	if err := c.OutboxService().MarkPublished(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	if pending() {
+		t.Fatal("unexpected pending event")
+	}
+}
@@ -14,13 +14,20 @@ var _ hippo.StoreService = &StoreService{}
 // StoreService holds event service and InfluxDB client connection.
 type StoreService struct {
 	// Services
-	eventService EventService
+	eventService  EventService
+	outboxService OutboxService
 
 	// client to connect to InfluxDB
 	db db.Client
 
 	// database to be used in InfluxDB
 	database string
+
+	// outbox records created events as unpublished
+	outbox bool
+
+	// singleWriter declares the store as the only writer of the database
+	singleWriter bool
 }
 
 // Config represents a configuration to initialize a new InfluxDB
@@ -36,12 +43,22 @@ type Config struct {
 
 	// Database is the influxdb to be used, defaults to "hippodb"
 	Database string
+
+	// Outbox records every created event as unpublished, so that the events
+	// can be relayed through the OutboxService, optional.
+	Outbox bool
+
+	// SingleWriter declares this store as the only writer of the database, e.g.
+	// behind the gRPC server, so that its version checks, serialized within the
+	// process, hold for all writers and hippo.AtomicAppend applies, optional.
+	SingleWriter bool
 }
 
 // NewStoreService creates a new StoreService
 func NewStoreService() *StoreService {
 	s := &StoreService{}
 	s.eventService.store = s
+	s.outboxService.store = s
 	return s
 }
 
@@ -76,6 +93,8 @@ func (s *StoreService) Connect(conf Config) error {
 		conf.Database = "hippo_db"
 	}
 	s.database = conf.Database
+	s.outbox = conf.Outbox
+	s.singleWriter = conf.SingleWriter
 
 	// Note: If you attempt to create a database that already exists,
 	// InfluxDB does nothing and does not return an error.
@@ -118,3 +137,6 @@ func (s *StoreService) NewPoint(name string, tags map[string]string,
 
 // EventService returns the event service associated with the client.
 func (s *StoreService) EventService() hippo.EventService { return &s.eventService }
+
+// OutboxService returns the outbox service associated with the client.
+func (s *StoreService) OutboxService() hippo.OutboxService { return &s.outboxService }
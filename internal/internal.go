@@ -6,7 +6,7 @@ import (
 	"github.com/golang/protobuf/ptypes"
 )
 
-// go:generate protoc --go_out=plugins=grpc,paths=source_relative:. influxdb/internal/internal.proto
+// go:generate protoc --go_out=plugins=grpc,paths=source_relative:. internal/internal.proto
 
 func mapHippoToProto(e *hippo.Event) (*Event, error) {
 	t, err := ptypes.TimestampProto(e.CreateTime)
@@ -49,6 +49,16 @@ func mapProtoToHippo(pb *Event, e *hippo.Event) error {
 	return nil
 }
 
+// EncodeEvent returns the protobuf message of the event, e.g. to send it over gRPC.
+func EncodeEvent(e *hippo.Event) (*Event, error) {
+	return mapHippoToProto(e)
+}
+
+// DecodeEvent decodes a event from its protobuf message.
+func DecodeEvent(pb *Event, e *hippo.Event) error {
+	return mapProtoToHippo(pb, e)
+}
+
 // MarshalEvent encodes a event to binary format.
 func MarshalEvent(e *hippo.Event) ([]byte, error) {
 	m, err := mapHippoToProto(e)
@@ -1,5 +1,5 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
-// source: influxdb/internal/internal.proto
+// source: internal/internal.proto
 
 package internal
 
@@ -52,7 +52,7 @@ func (x Format) String() string {
 }
 
 func (Format) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_7488a9414d9db925, []int{0}
+	return fileDescriptor_41ca0a4a9dd77d9e, []int{0}
 }
 
 // Event resource.
@@ -90,7 +90,7 @@ func (m *Event) Reset()         { *m = Event{} }
 func (m *Event) String() string { return proto.CompactTextString(m) }
 func (*Event) ProtoMessage()    {}
 func (*Event) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7488a9414d9db925, []int{0}
+	return fileDescriptor_41ca0a4a9dd77d9e, []int{0}
 }
 
 func (m *Event) XXX_Unmarshal(b []byte) error {
@@ -208,7 +208,7 @@ func (m *CreateEventRequest) Reset()         { *m = CreateEventRequest{} }
 func (m *CreateEventRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateEventRequest) ProtoMessage()    {}
 func (*CreateEventRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7488a9414d9db925, []int{1}
+	return fileDescriptor_41ca0a4a9dd77d9e, []int{1}
 }
 
 func (m *CreateEventRequest) XXX_Unmarshal(b []byte) error {
@@ -249,7 +249,7 @@ func (m *SnapshotEventRequest) Reset()         { *m = SnapshotEventRequest{} }
 func (m *SnapshotEventRequest) String() string { return proto.CompactTextString(m) }
 func (*SnapshotEventRequest) ProtoMessage()    {}
 func (*SnapshotEventRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7488a9414d9db925, []int{2}
+	return fileDescriptor_41ca0a4a9dd77d9e, []int{2}
 }
 
 func (m *SnapshotEventRequest) XXX_Unmarshal(b []byte) error {
@@ -291,7 +291,7 @@ func (m *GetEventRequest) Reset()         { *m = GetEventRequest{} }
 func (m *GetEventRequest) String() string { return proto.CompactTextString(m) }
 func (*GetEventRequest) ProtoMessage()    {}
 func (*GetEventRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7488a9414d9db925, []int{3}
+	return fileDescriptor_41ca0a4a9dd77d9e, []int{3}
 }
 
 func (m *GetEventRequest) XXX_Unmarshal(b []byte) error {
@@ -329,9 +329,12 @@ func (m *GetEventRequest) GetVersion() int64 {
 // Request message for ListEvents method.
 type ListEventsRequest struct {
 	// The ID of the event is based on the aggregate_id and version
-	AggregateId          string   `protobuf:"bytes,1,opt,name=aggregate_id,json=aggregateId,proto3" json:"aggregate_id,omitempty"`
-	VersionMin           int64    `protobuf:"varint,2,opt,name=version_min,json=versionMin,proto3" json:"version_min,omitempty"`
-	VersionMax           int64    `protobuf:"varint,3,opt,name=version_max,json=versionMax,proto3" json:"version_max,omitempty"`
+	AggregateId string `protobuf:"bytes,1,opt,name=aggregate_id,json=aggregateId,proto3" json:"aggregate_id,omitempty"`
+	VersionMin  int64  `protobuf:"varint,2,opt,name=version_min,json=versionMin,proto3" json:"version_min,omitempty"`
+	VersionMax  int64  `protobuf:"varint,3,opt,name=version_max,json=versionMax,proto3" json:"version_max,omitempty"`
+	// Origin of the events. All origins if empty.
+	OriginName           string   `protobuf:"bytes,4,opt,name=origin_name,json=originName,proto3" json:"origin_name,omitempty"`
+	OriginIp             string   `protobuf:"bytes,5,opt,name=origin_ip,json=originIp,proto3" json:"origin_ip,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -341,7 +344,7 @@ func (m *ListEventsRequest) Reset()         { *m = ListEventsRequest{} }
 func (m *ListEventsRequest) String() string { return proto.CompactTextString(m) }
 func (*ListEventsRequest) ProtoMessage()    {}
 func (*ListEventsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7488a9414d9db925, []int{4}
+	return fileDescriptor_41ca0a4a9dd77d9e, []int{4}
 }
 
 func (m *ListEventsRequest) XXX_Unmarshal(b []byte) error {
@@ -383,6 +386,162 @@ func (m *ListEventsRequest) GetVersionMax() int64 {
 	return 0
 }
 
+func (m *ListEventsRequest) GetOriginName() string {
+	if m != nil {
+		return m.OriginName
+	}
+	return ""
+}
+
+func (m *ListEventsRequest) GetOriginIp() string {
+	if m != nil {
+		return m.OriginIp
+	}
+	return ""
+}
+
+// Request message for GetLastVersion method.
+type GetLastVersionRequest struct {
+	// Aggregate ID of the events.
+	AggregateId          string   `protobuf:"bytes,1,opt,name=aggregate_id,json=aggregateId,proto3" json:"aggregate_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetLastVersionRequest) Reset()         { *m = GetLastVersionRequest{} }
+func (m *GetLastVersionRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLastVersionRequest) ProtoMessage()    {}
+func (*GetLastVersionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41ca0a4a9dd77d9e, []int{5}
+}
+
+func (m *GetLastVersionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLastVersionRequest.Unmarshal(m, b)
+}
+func (m *GetLastVersionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLastVersionRequest.Marshal(b, m, deterministic)
+}
+func (m *GetLastVersionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLastVersionRequest.Merge(m, src)
+}
+func (m *GetLastVersionRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLastVersionRequest.Size(m)
+}
+func (m *GetLastVersionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLastVersionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLastVersionRequest proto.InternalMessageInfo
+
+func (m *GetLastVersionRequest) GetAggregateId() string {
+	if m != nil {
+		return m.AggregateId
+	}
+	return ""
+}
+
+// Response message for GetLastVersion method.
+type GetLastVersionResponse struct {
+	// Version of the last event of the aggregate, zero if it has no events.
+	Version              int64    `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetLastVersionResponse) Reset()         { *m = GetLastVersionResponse{} }
+func (m *GetLastVersionResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLastVersionResponse) ProtoMessage()    {}
+func (*GetLastVersionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41ca0a4a9dd77d9e, []int{6}
+}
+
+func (m *GetLastVersionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLastVersionResponse.Unmarshal(m, b)
+}
+func (m *GetLastVersionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLastVersionResponse.Marshal(b, m, deterministic)
+}
+func (m *GetLastVersionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLastVersionResponse.Merge(m, src)
+}
+func (m *GetLastVersionResponse) XXX_Size() int {
+	return xxx_messageInfo_GetLastVersionResponse.Size(m)
+}
+func (m *GetLastVersionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLastVersionResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLastVersionResponse proto.InternalMessageInfo
+
+func (m *GetLastVersionResponse) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+// Request message for Subscribe method.
+type SubscribeRequest struct {
+	// Topics of the events, topic patterns are supported. All topics if empty.
+	Topics []string `protobuf:"bytes,1,rep,name=topics,proto3" json:"topics,omitempty"`
+	// Aggregate ID of the events. All aggregates if empty.
+	AggregateId string `protobuf:"bytes,2,opt,name=aggregate_id,json=aggregateId,proto3" json:"aggregate_id,omitempty"`
+	// Events created after this time are streamed before the live events.
+	// Only live events are streamed if not set.
+	After                *timestamp.Timestamp `protobuf:"bytes,3,opt,name=after,proto3" json:"after,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *SubscribeRequest) Reset()         { *m = SubscribeRequest{} }
+func (m *SubscribeRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeRequest) ProtoMessage()    {}
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41ca0a4a9dd77d9e, []int{7}
+}
+
+func (m *SubscribeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubscribeRequest.Unmarshal(m, b)
+}
+func (m *SubscribeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubscribeRequest.Marshal(b, m, deterministic)
+}
+func (m *SubscribeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeRequest.Merge(m, src)
+}
+func (m *SubscribeRequest) XXX_Size() int {
+	return xxx_messageInfo_SubscribeRequest.Size(m)
+}
+func (m *SubscribeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscribeRequest proto.InternalMessageInfo
+
+func (m *SubscribeRequest) GetTopics() []string {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
+func (m *SubscribeRequest) GetAggregateId() string {
+	if m != nil {
+		return m.AggregateId
+	}
+	return ""
+}
+
+func (m *SubscribeRequest) GetAfter() *timestamp.Timestamp {
+	if m != nil {
+		return m.After
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterEnum("internal.Format", Format_name, Format_value)
 	proto.RegisterType((*Event)(nil), "internal.Event")
@@ -391,52 +550,61 @@ func init() {
 	proto.RegisterType((*SnapshotEventRequest)(nil), "internal.SnapshotEventRequest")
 	proto.RegisterType((*GetEventRequest)(nil), "internal.GetEventRequest")
 	proto.RegisterType((*ListEventsRequest)(nil), "internal.ListEventsRequest")
-}
-
-func init() { proto.RegisterFile("influxdb/internal/internal.proto", fileDescriptor_7488a9414d9db925) }
-
-var fileDescriptor_7488a9414d9db925 = []byte{
-	// 627 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x94, 0x5f, 0x6f, 0xd3, 0x3c,
-	0x14, 0xc6, 0xe7, 0x76, 0xed, 0xd2, 0x93, 0xee, 0x5d, 0xdf, 0xa3, 0x69, 0x0a, 0xd9, 0x60, 0xa1,
-	0x12, 0x52, 0xf8, 0xa3, 0x14, 0x95, 0x9b, 0xc1, 0x04, 0x17, 0x9b, 0xba, 0xa9, 0x88, 0x75, 0x90,
-	0x8d, 0x1b, 0x6e, 0x26, 0xb7, 0xf5, 0x52, 0x6b, 0x4d, 0x1c, 0x12, 0xa7, 0x6a, 0x2f, 0xf9, 0x1c,
-	0x7c, 0x36, 0xbe, 0x0b, 0x8a, 0x93, 0x36, 0xb4, 0x1d, 0x48, 0x70, 0xe7, 0xf3, 0x9c, 0xc7, 0x4f,
-	0x6d, 0xff, 0x4e, 0x03, 0x16, 0x0f, 0x6e, 0xc7, 0xc9, 0x74, 0xd8, 0x6f, 0xf1, 0x40, 0xb2, 0x28,
-	0xa0, 0xe3, 0xc5, 0xc2, 0x09, 0x23, 0x21, 0x05, 0x6a, 0xf3, 0xda, 0x3c, 0xf4, 0x84, 0xf0, 0xc6,
-	0xac, 0xa5, 0xf4, 0x7e, 0x72, 0xdb, 0x92, 0xdc, 0x67, 0xb1, 0xa4, 0x7e, 0x98, 0x59, 0xcd, 0xfd,
-	0x55, 0x03, 0xf3, 0x43, 0x39, 0xcb, 0x9a, 0xcd, 0x1f, 0x65, 0xa8, 0x74, 0x26, 0x2c, 0x90, 0xb8,
-	0x0b, 0x15, 0x29, 0x42, 0x3e, 0x30, 0x88, 0x45, 0xec, 0x9a, 0x9b, 0x15, 0xf8, 0x18, 0xea, 0xd4,
-	0xf3, 0x22, 0xe6, 0x51, 0xc9, 0x6e, 0xf8, 0xd0, 0x28, 0xa9, 0xa6, 0xbe, 0xd0, 0xba, 0x43, 0x34,
-	0x60, 0x6b, 0xc2, 0xa2, 0x98, 0x8b, 0xc0, 0x28, 0x5b, 0xc4, 0x2e, 0xbb, 0xf3, 0x12, 0xf7, 0xa0,
-	0x1a, 0x0f, 0x46, 0xcc, 0xa7, 0xc6, 0xa6, 0xda, 0x96, 0x57, 0x68, 0x43, 0xf5, 0x56, 0x44, 0x3e,
-	0x95, 0x46, 0xc5, 0x22, 0xf6, 0x7f, 0xed, 0x86, 0xb3, 0xb8, 0xdd, 0x99, 0xd2, 0xdd, 0xbc, 0x8f,
-	0x08, 0x9b, 0x43, 0x2a, 0xa9, 0x51, 0xb5, 0x88, 0x5d, 0x77, 0xd5, 0x1a, 0x4d, 0xd0, 0xc2, 0x88,
-	0x8b, 0x88, 0xcb, 0x99, 0xb1, 0x65, 0x11, 0xbb, 0xe2, 0x2e, 0x6a, 0x3c, 0x80, 0x5a, 0xcc, 0xbd,
-	0x80, 0xca, 0x24, 0x62, 0x86, 0xa6, 0x7e, 0xb4, 0x10, 0xf0, 0x10, 0x74, 0x11, 0x71, 0x8f, 0x07,
-	0x37, 0x01, 0xf5, 0x99, 0x51, 0x53, 0x7d, 0xc8, 0xa4, 0x1e, 0xf5, 0x19, 0xee, 0x43, 0x2d, 0x37,
-	0xf0, 0xd0, 0x00, 0xd5, 0xd6, 0x32, 0xa1, 0x1b, 0xe2, 0x6b, 0xd0, 0x7c, 0x26, 0xa9, 0x3a, 0x8f,
-	0x6e, 0x95, 0x6d, 0xbd, 0xfd, 0xb0, 0x38, 0xb7, 0x7a, 0x43, 0xe7, 0x22, 0xef, 0x77, 0x02, 0x19,
-	0xcd, 0xdc, 0x85, 0x1d, 0x8f, 0x41, 0x1f, 0x44, 0x2c, 0x7d, 0xc2, 0x14, 0x8e, 0x51, 0xb7, 0x88,
-	0xad, 0xb7, 0x4d, 0x27, 0x03, 0xe3, 0xcc, 0xc1, 0x38, 0xd7, 0x73, 0x72, 0x2e, 0x64, 0xf6, 0x54,
-	0x30, 0x8f, 0x61, 0x7b, 0x29, 0x17, 0x1b, 0x50, 0xbe, 0x63, 0xb3, 0x9c, 0x53, 0xba, 0x4c, 0xd9,
-	0x4d, 0xe8, 0x38, 0x61, 0x39, 0x9e, 0xac, 0x78, 0x53, 0x3a, 0x22, 0xcd, 0x63, 0xc0, 0x53, 0x15,
-	0xa5, 0x0e, 0xe8, 0xb2, 0xaf, 0x09, 0x8b, 0x25, 0x3e, 0x81, 0x0a, 0x4b, 0x6b, 0x95, 0xa1, 0xb7,
-	0x77, 0x56, 0xee, 0xe1, 0x66, 0xdd, 0xe6, 0x5b, 0xd8, 0xbd, 0x0a, 0x68, 0x18, 0x8f, 0x84, 0xfc,
-	0x97, 0xed, 0x3d, 0xd8, 0x39, 0x67, 0xcb, 0x3b, 0x57, 0xc7, 0x89, 0xfc, 0x71, 0x9c, 0x4a, 0x4b,
-	0xe3, 0xd4, 0x9c, 0xc2, 0xff, 0x1f, 0x78, 0x9c, 0x05, 0xc6, 0x7f, 0x91, 0x78, 0x08, 0x7a, 0x1e,
-	0x71, 0xe3, 0xf3, 0x79, 0x2a, 0xe4, 0xd2, 0x05, 0x0f, 0x96, 0x0c, 0x74, 0x9a, 0x4f, 0xf1, 0xc2,
-	0x40, 0xa7, 0xcf, 0x5e, 0x40, 0x35, 0x1b, 0x4c, 0xac, 0x83, 0xf6, 0xd1, 0xbd, 0xbc, 0xbe, 0x3c,
-	0xf9, 0x7c, 0xd6, 0xd8, 0x40, 0x0d, 0x36, 0xdf, 0x5f, 0x5d, 0xf6, 0x1a, 0x04, 0x01, 0xaa, 0x57,
-	0xd7, 0x6e, 0xb7, 0x77, 0xde, 0x28, 0xb5, 0xbf, 0x13, 0xd8, 0x3a, 0x15, 0xbe, 0x4f, 0x83, 0x21,
-	0x76, 0x40, 0xff, 0xe5, 0xfd, 0xf1, 0xa0, 0x78, 0xaa, 0x75, 0x2c, 0xe6, 0xde, 0xda, 0x44, 0x74,
-	0xd2, 0xbf, 0x6a, 0x73, 0x03, 0xbb, 0xb0, 0xbd, 0x44, 0x02, 0x1f, 0x15, 0x41, 0xf7, 0x21, 0xfa,
-	0x7d, 0x54, 0xfb, 0x1b, 0x81, 0xca, 0xa7, 0x84, 0x45, 0x33, 0x3c, 0x02, 0x6d, 0xce, 0x07, 0x1f,
-	0x14, 0x79, 0x2b, 0xcc, 0xcc, 0x55, 0xbc, 0xcd, 0x0d, 0x7c, 0x07, 0x50, 0x90, 0xc0, 0xfd, 0xc2,
-	0xb0, 0xc6, 0xe7, 0x9e, 0xdd, 0x2f, 0xc9, 0xc9, 0xf3, 0x2f, 0x4f, 0x3d, 0x2e, 0x47, 0x49, 0xdf,
-	0x19, 0x08, 0xbf, 0x45, 0x93, 0xbb, 0x3e, 0x97, 0xad, 0x11, 0x0f, 0x43, 0xd1, 0x5a, 0xfb, 0xf2,
-	0xf5, 0xab, 0xea, 0x0a, 0xaf, 0x7e, 0x06, 0x00, 0x00, 0xff, 0xff, 0x4f, 0xf7, 0xdd, 0xbd, 0x15,
-	0x05, 0x00, 0x00,
+	proto.RegisterType((*GetLastVersionRequest)(nil), "internal.GetLastVersionRequest")
+	proto.RegisterType((*GetLastVersionResponse)(nil), "internal.GetLastVersionResponse")
+	proto.RegisterType((*SubscribeRequest)(nil), "internal.SubscribeRequest")
+}
+
+func init() { proto.RegisterFile("internal/internal.proto", fileDescriptor_41ca0a4a9dd77d9e) }
+
+var fileDescriptor_41ca0a4a9dd77d9e = []byte{
+	// 733 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x95, 0xcd, 0x4e, 0xeb, 0x46,
+	0x14, 0xc7, 0xe3, 0x38, 0x0e, 0xce, 0x71, 0x80, 0xf4, 0x88, 0xa6, 0xae, 0xa1, 0x8d, 0x6b, 0xa9,
+	0x92, 0x55, 0x55, 0x09, 0x4a, 0x37, 0x14, 0xda, 0x2e, 0x40, 0x01, 0xa5, 0x82, 0xd0, 0x3a, 0xd0,
+	0x45, 0x37, 0x68, 0x92, 0x0c, 0xce, 0x08, 0xfc, 0x51, 0x7b, 0x8c, 0xc8, 0xaa, 0x6f, 0xd1, 0x4d,
+	0xdf, 0xe3, 0xbe, 0xcd, 0x7d, 0x97, 0x2b, 0x7f, 0x25, 0xd8, 0x01, 0x2e, 0xf7, 0xee, 0xe6, 0x7c,
+	0xcc, 0x7f, 0x8e, 0xcf, 0xf9, 0x9d, 0x04, 0xbe, 0x62, 0x2e, 0xa7, 0x81, 0x4b, 0xee, 0x7b, 0xf9,
+	0xa1, 0xeb, 0x07, 0x1e, 0xf7, 0x50, 0xce, 0x6d, 0xad, 0x63, 0x7b, 0x9e, 0x7d, 0x4f, 0x7b, 0x89,
+	0x7f, 0x12, 0xdd, 0xf6, 0x38, 0x73, 0x68, 0xc8, 0x89, 0xe3, 0xa7, 0xa9, 0xda, 0x6e, 0x39, 0x81,
+	0x3a, 0x3e, 0x5f, 0xa4, 0x41, 0xe3, 0xbd, 0x08, 0xd2, 0xe0, 0x81, 0xba, 0x1c, 0x77, 0x40, 0xe2,
+	0x9e, 0xcf, 0xa6, 0xaa, 0xa0, 0x0b, 0x66, 0xc3, 0x4a, 0x0d, 0xfc, 0x0e, 0x9a, 0xc4, 0xb6, 0x03,
+	0x6a, 0x13, 0x4e, 0x6f, 0xd8, 0x4c, 0xad, 0x26, 0x41, 0x65, 0xe9, 0x1b, 0xce, 0x50, 0x85, 0x8d,
+	0x07, 0x1a, 0x84, 0xcc, 0x73, 0x55, 0x51, 0x17, 0x4c, 0xd1, 0xca, 0x4d, 0x6c, 0x43, 0x3d, 0x9c,
+	0xce, 0xa9, 0x43, 0xd4, 0x5a, 0x72, 0x2d, 0xb3, 0xd0, 0x84, 0xfa, 0xad, 0x17, 0x38, 0x84, 0xab,
+	0x92, 0x2e, 0x98, 0x5b, 0xfd, 0x56, 0x77, 0xf9, 0x75, 0xa7, 0x89, 0xdf, 0xca, 0xe2, 0x88, 0x50,
+	0x9b, 0x11, 0x4e, 0xd4, 0xba, 0x2e, 0x98, 0x4d, 0x2b, 0x39, 0xa3, 0x06, 0xb2, 0x1f, 0x30, 0x2f,
+	0x60, 0x7c, 0xa1, 0x6e, 0xe8, 0x82, 0x29, 0x59, 0x4b, 0x1b, 0xf7, 0xa0, 0x11, 0x32, 0xdb, 0x25,
+	0x3c, 0x0a, 0xa8, 0x2a, 0x27, 0x8f, 0xae, 0x1c, 0xd8, 0x01, 0xc5, 0x0b, 0x98, 0xcd, 0xdc, 0x1b,
+	0x97, 0x38, 0x54, 0x6d, 0x24, 0x71, 0x48, 0x5d, 0x23, 0xe2, 0x50, 0xdc, 0x85, 0x46, 0x96, 0xc0,
+	0x7c, 0x15, 0x92, 0xb0, 0x9c, 0x3a, 0x86, 0x3e, 0xfe, 0x0c, 0xb2, 0x43, 0x39, 0x49, 0xea, 0x51,
+	0x74, 0xd1, 0x54, 0xfa, 0xdf, 0xac, 0xea, 0x4e, 0x7a, 0xd8, 0xbd, 0xc8, 0xe2, 0x03, 0x97, 0x07,
+	0x0b, 0x6b, 0x99, 0x8e, 0x47, 0xa0, 0x4c, 0x03, 0x1a, 0xb7, 0x30, 0x1e, 0x8e, 0xda, 0xd4, 0x05,
+	0x53, 0xe9, 0x6b, 0xdd, 0x74, 0x30, 0xdd, 0x7c, 0x30, 0xdd, 0xab, 0x7c, 0x72, 0x16, 0xa4, 0xe9,
+	0xb1, 0x43, 0x3b, 0x82, 0xcd, 0x82, 0x2e, 0xb6, 0x40, 0xbc, 0xa3, 0x8b, 0x6c, 0x4e, 0xf1, 0x31,
+	0x9e, 0xdd, 0x03, 0xb9, 0x8f, 0x68, 0x36, 0x9e, 0xd4, 0x38, 0xac, 0x1e, 0x08, 0xc6, 0x11, 0xe0,
+	0x49, 0x22, 0x95, 0x14, 0x68, 0xd1, 0x7f, 0x22, 0x1a, 0x72, 0xfc, 0x1e, 0x24, 0x1a, 0xdb, 0x89,
+	0x86, 0xd2, 0xdf, 0x2e, 0x7d, 0x87, 0x95, 0x46, 0x8d, 0x5f, 0x61, 0x67, 0xec, 0x12, 0x3f, 0x9c,
+	0x7b, 0xfc, 0x73, 0xae, 0x8f, 0x60, 0xfb, 0x8c, 0x16, 0x6f, 0x96, 0x71, 0x12, 0x5e, 0xc5, 0xa9,
+	0x5a, 0xc0, 0xc9, 0x78, 0x27, 0xc0, 0x17, 0xe7, 0x2c, 0x4c, 0x15, 0xc3, 0x4f, 0x90, 0xec, 0x80,
+	0x92, 0x69, 0xdc, 0x38, 0x2c, 0x97, 0x85, 0xcc, 0x75, 0xc1, 0xdc, 0x42, 0x02, 0x79, 0x54, 0xc5,
+	0x62, 0x02, 0x79, 0x2c, 0x93, 0x53, 0x7b, 0x9d, 0x1c, 0xa9, 0x48, 0x8e, 0x71, 0x08, 0x5f, 0x9e,
+	0x51, 0x7e, 0x4e, 0x42, 0xfe, 0x57, 0x2a, 0xf9, 0xf6, 0xda, 0x8d, 0x3e, 0xb4, 0xcb, 0x77, 0x43,
+	0xdf, 0x73, 0x43, 0xfa, 0xb4, 0x51, 0x42, 0xb1, 0x51, 0xff, 0x42, 0x6b, 0x1c, 0x4d, 0xc2, 0x69,
+	0xc0, 0x26, 0x34, 0x7f, 0xaa, 0x0d, 0xf5, 0x64, 0xa3, 0x43, 0x55, 0xd0, 0xc5, 0x78, 0x17, 0x53,
+	0xeb, 0x2d, 0x0b, 0xbe, 0x0f, 0x12, 0xb9, 0xe5, 0x34, 0x50, 0xc5, 0x8f, 0x72, 0x9b, 0x26, 0xfe,
+	0xf0, 0x23, 0xd4, 0xd3, 0x45, 0xc6, 0x26, 0xc8, 0x7f, 0x58, 0x97, 0x57, 0x97, 0xc7, 0xd7, 0xa7,
+	0xad, 0x0a, 0xca, 0x50, 0xfb, 0x7d, 0x7c, 0x39, 0x6a, 0x09, 0x08, 0x50, 0x1f, 0x5f, 0x59, 0xc3,
+	0xd1, 0x59, 0xab, 0xda, 0xff, 0x5f, 0x80, 0x8d, 0x13, 0xcf, 0x71, 0x88, 0x3b, 0xc3, 0x01, 0x28,
+	0x4f, 0x78, 0xc5, 0xbd, 0x15, 0x5a, 0xeb, 0x18, 0x6b, 0xed, 0xb5, 0x4a, 0x06, 0xf1, 0x4f, 0x9b,
+	0x51, 0xc1, 0x21, 0x6c, 0x16, 0xc8, 0xc5, 0x6f, 0x57, 0x42, 0xcf, 0x21, 0xfd, 0xb2, 0x54, 0xff,
+	0xbf, 0x2a, 0x48, 0x7f, 0x46, 0x34, 0x58, 0xe0, 0x01, 0xc8, 0x39, 0xcf, 0xf8, 0xf5, 0x4a, 0xaf,
+	0xc4, 0xb8, 0x56, 0x5e, 0x07, 0xa3, 0x82, 0xbf, 0x01, 0xac, 0xc0, 0xc5, 0xdd, 0x55, 0xc2, 0x1a,
+	0xce, 0xcf, 0xdc, 0xde, 0x17, 0xf0, 0x1a, 0xb6, 0x8a, 0x10, 0x60, 0xa7, 0xf0, 0xfe, 0x3a, 0x5a,
+	0x9a, 0xfe, 0x72, 0x42, 0xca, 0x8f, 0x51, 0xc1, 0x5f, 0xa0, 0xb1, 0xe4, 0x04, 0xb5, 0x27, 0x1d,
+	0x2a, 0xc1, 0xf3, 0x6c, 0x51, 0xc7, 0xc6, 0xdf, 0xba, 0xcd, 0xf8, 0x3c, 0x9a, 0x74, 0xa7, 0x9e,
+	0xd3, 0x23, 0xd1, 0xdd, 0x84, 0xf1, 0xde, 0x9c, 0xf9, 0xbe, 0xb7, 0xfc, 0xb3, 0x9a, 0xd4, 0x93,
+	0x76, 0xfe, 0xf4, 0x61, 0x00, 0x39, 0x19, 0xc9, 0x79, 0xc8, 0x06, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -552,7 +720,7 @@ var _Command_serviceDesc = grpc.ServiceDesc{
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
-	Metadata: "influxdb/internal/internal.proto",
+	Metadata: "internal/internal.proto",
 }
 
 // QueryClient is the client API for Query service.
@@ -561,6 +729,8 @@ var _Command_serviceDesc = grpc.ServiceDesc{
 type QueryClient interface {
 	GetEvent(ctx context.Context, in *GetEventRequest, opts ...grpc.CallOption) (*Event, error)
 	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (Query_ListEventsClient, error)
+	GetLastVersion(ctx context.Context, in *GetLastVersionRequest, opts ...grpc.CallOption) (*GetLastVersionResponse, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Query_SubscribeClient, error)
 }
 
 type queryClient struct {
@@ -612,10 +782,53 @@ func (x *queryListEventsClient) Recv() (*Event, error) {
 	return m, nil
 }
 
+func (c *queryClient) GetLastVersion(ctx context.Context, in *GetLastVersionRequest, opts ...grpc.CallOption) (*GetLastVersionResponse, error) {
+	out := new(GetLastVersionResponse)
+	err := c.cc.Invoke(ctx, "/internal.Query/GetLastVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Query_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Query_serviceDesc.Streams[1], "/internal.Query/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &querySubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Query_SubscribeClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type querySubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *querySubscribeClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // QueryServer is the server API for Query service.
 type QueryServer interface {
 	GetEvent(context.Context, *GetEventRequest) (*Event, error)
 	ListEvents(*ListEventsRequest, Query_ListEventsServer) error
+	GetLastVersion(context.Context, *GetLastVersionRequest) (*GetLastVersionResponse, error)
+	Subscribe(*SubscribeRequest, Query_SubscribeServer) error
 }
 
 // UnimplementedQueryServer can be embedded to have forward compatible implementations.
@@ -628,6 +841,12 @@ func (*UnimplementedQueryServer) GetEvent(ctx context.Context, req *GetEventRequ
 func (*UnimplementedQueryServer) ListEvents(req *ListEventsRequest, srv Query_ListEventsServer) error {
 	return status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
 }
+func (*UnimplementedQueryServer) GetLastVersion(ctx context.Context, req *GetLastVersionRequest) (*GetLastVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLastVersion not implemented")
+}
+func (*UnimplementedQueryServer) Subscribe(req *SubscribeRequest, srv Query_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
 
 func RegisterQueryServer(s *grpc.Server, srv QueryServer) {
 	s.RegisterService(&_Query_serviceDesc, srv)
@@ -672,6 +891,45 @@ func (x *queryListEventsServer) Send(m *Event) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Query_GetLastVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLastVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetLastVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/internal.Query/GetLastVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetLastVersion(ctx, req.(*GetLastVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueryServer).Subscribe(m, &querySubscribeServer{stream})
+}
+
+type Query_SubscribeServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type querySubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *querySubscribeServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Query_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "internal.Query",
 	HandlerType: (*QueryServer)(nil),
@@ -680,6 +938,10 @@ var _Query_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetEvent",
 			Handler:    _Query_GetEvent_Handler,
 		},
+		{
+			MethodName: "GetLastVersion",
+			Handler:    _Query_GetLastVersion_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -687,6 +949,11 @@ var _Query_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Query_ListEvents_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Query_Subscribe_Handler,
+			ServerStreams: true,
+		},
 	},
-	Metadata: "influxdb/internal/internal.proto",
+	Metadata: "internal/internal.proto",
 }
@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/aukbit/hippo"
-	"github.com/aukbit/hippo/influxdb/internal"
+	"github.com/aukbit/hippo/internal"
 )
 
 // Ensure event can be marshaled and unmarshaled.
@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+)
+
+var _ hippo.Publisher = &Publisher{}
+
+// Producer represents a Kafka producer, e.g. a thin wrapper around a kafka-go
+// Writer or a sarama SyncProducer.
+type Producer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// Publisher publishes events to Kafka topics.
+type Publisher struct {
+	// producer connected to Kafka
+	producer Producer
+
+	// topic all events are published to
+	topic string
+}
+
+// Config represents a configuration to initialize a new Publisher
+type Config struct {
+	// Topic is the Kafka topic all events are published to, optional.
+	// If empty each event is published to the Kafka topic named after the event topic.
+	Topic string
+}
+
+// NewPublisher creates a new Publisher
+func NewPublisher(producer Producer, conf Config) *Publisher {
+	return &Publisher{
+		producer: producer,
+		topic:    conf.Topic,
+	}
+}
+
+// Publish encodes the event in the internal.Event protobuf envelope and produces
+// it keyed by aggregate ID, so that the events of an aggregate keep their order
+// within a Kafka partition.
+func (p *Publisher) Publish(ctx context.Context, e *hippo.Event) error {
+	start := time.Now()
+	value, err := internal.MarshalEvent(e)
+	if err != nil {
+		return err
+	}
+	topic := p.topic
+	if topic == "" {
+		topic = e.Topic
+	}
+	if err := p.producer.Produce(ctx, topic, []byte(e.AggregateID), value); err != nil {
+		return err
+	}
+	log.Printf("kafka: event %s with aggregate %s version %d published to %s - duration: %v", e.Topic, e.AggregateID, e.Version, topic, time.Now().Sub(start))
+	return nil
+}
+
+// Decode decodes an event from the value of a Kafka message.
+func Decode(value []byte) (*hippo.Event, error) {
+	e := &hippo.Event{}
+	if err := internal.UnmarshalEvent(value, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
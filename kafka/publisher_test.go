@@ -0,0 +1,58 @@
+package kafka_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/kafka"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+)
+
+// Message is a message produced to Kafka.
+type Message struct {
+	Topic string
+	Key   []byte
+	Value []byte
+}
+
+// Producer is a local stand-in for a Kafka producer.
+type Producer struct {
+	messages []Message
+}
+
+// Produce records the produced message.
+func (p *Producer) Produce(ctx context.Context, topic string, key, value []byte) error {
+	p.messages = append(p.messages, Message{Topic: topic, Key: key, Value: value})
+	return nil
+}
+
+// Ensure event is produced keyed by aggregate and can be decoded.
+func TestPublisher_Publish(t *testing.T) {
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+	event := hippo.NewEventProto("user_created", user.GetId(), &user)
+	event.Version = 1
+
+	producer := &Producer{}
+	p := kafka.NewPublisher(producer, kafka.Config{Topic: "hippo_events"})
+
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(producer.messages))
+	assert.Equal(t, "hippo_events", producer.messages[0].Topic)
+	assert.Equal(t, user.GetId(), string(producer.messages[0].Key))
+
+	if other, err := kafka.Decode(producer.messages[0].Value); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(event, other) {
+		t.Fatalf("unexpected copy: %#v != %#v", event, other)
+	}
+}
@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/golang/protobuf/proto"
+)
+
+var _ hippo.CacheService = &CacheService{}
+var _ hippo.CacheDeleter = &CacheService{}
+
+// CacheService holds aggregates in memory, evicting the least recently used.
+type CacheService struct {
+	mu sync.Mutex
+
+	// entries by key and their recency, front is the most recently used
+	entries map[string]*list.Element
+	lru     *list.List
+
+	// size of all the cached states in bytes
+	size int64
+
+	conf Config
+	now  func() time.Time
+
+	stats Stats
+}
+
+// Config represents a configuration to initialize a new CacheService
+type Config struct {
+	// MaxEntries is the maximum number of cached aggregates, defaults to 1000.
+	MaxEntries int
+
+	// MaxSize is the maximum size in bytes of all the cached states, optional.
+	MaxSize int64
+
+	// TTL is the expiry of the cached aggregates, optional.
+	// Aggregates never expire if not set.
+	TTL time.Duration
+}
+
+// Stats represents the cache statistics.
+type Stats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Entries   int
+	Size      int64
+}
+
+// entry represents a cached aggregate, holding a clone of a proto state
+// or the JSON encoding of any other state.
+type entry struct {
+	key      string
+	version  int64
+	state    proto.Message
+	data     []byte
+	size     int64
+	expireAt time.Time
+}
+
+// NewCacheService creates a new CacheService
+func NewCacheService(conf Config) *CacheService {
+	if conf.MaxEntries <= 0 {
+		conf.MaxEntries = 1000
+	}
+	return &CacheService{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		conf:    conf,
+		now:     time.Now,
+	}
+}
+
+// key returns the key of the aggregate, namespaced by the domain type.
+func key(aggregateID string, domainType interface{}) string {
+	return fmt.Sprintf("%T:%s", domainType, aggregateID)
+}
+
+// decode returns a new value of the type of buffer with the JSON encoded data.
+func decode(data []byte, buffer interface{}) (interface{}, error) {
+	t := reflect.TypeOf(buffer)
+	if t == nil {
+		return nil, hippo.ErrFormatNotProvided
+	}
+	if t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// Get returns a deep copy of the cached aggregate for the respective aggregateID.
+func (s *CacheService) Get(ctx context.Context, aggregateID string, out *hippo.Aggregate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key(aggregateID, out.State)]
+	if !ok {
+		s.stats.Misses++
+		return hippo.ErrKeyDoesNotExist
+	}
+	e := el.Value.(*entry)
+	if !e.expireAt.IsZero() && !s.now().Before(e.expireAt) {
+		s.remove(el)
+		s.stats.Misses++
+		return hippo.ErrKeyDoesNotExist
+	}
+
+	var state interface{}
+	if e.state != nil {
+		state = proto.Clone(e.state)
+	} else {
+		var err error
+		if state, err = decode(e.data, out.State); err != nil {
+			return err
+		}
+	}
+	s.lru.MoveToFront(el)
+	s.stats.Hits++
+
+	out.Version = e.version
+	out.State = state
+	return nil
+}
+
+// Set stores a deep copy of the aggregate, so that later changes to the aggregate
+// state do not change the cached one. States that are not proto messages are stored
+// JSON encoded. The aggregate is only stored if its version is greater than the
+// version already cached, the same as in the Redis cache.
+func (s *CacheService) Set(ctx context.Context, aggregateID string, in *hippo.Aggregate) error {
+	e := &entry{
+		key:     key(aggregateID, in.State),
+		version: in.Version,
+	}
+	if m, ok := in.State.(proto.Message); ok {
+		e.state = proto.Clone(m)
+		e.size = int64(proto.Size(m))
+	} else {
+		data, err := json.Marshal(in.State)
+		if err != nil {
+			return err
+		}
+		e.data = data
+		e.size = int64(len(data))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[e.key]; ok {
+		if el.Value.(*entry).version >= in.Version {
+			return nil
+		}
+		s.remove(el)
+	}
+
+	if s.conf.TTL > 0 {
+		e.expireAt = s.now().Add(s.conf.TTL)
+	}
+	s.entries[e.key] = s.lru.PushFront(e)
+	s.size += e.size
+
+	// Evict least recently used aggregates while over the bounds.
+	for s.lru.Len() > s.conf.MaxEntries || (s.conf.MaxSize > 0 && s.size > s.conf.MaxSize && s.lru.Len() > 1) {
+		s.remove(s.lru.Back())
+		s.stats.Evictions++
+	}
+	return nil
+}
+
+// Delete invalidates the cached aggregate of the given domain type.
+func (s *CacheService) Delete(ctx context.Context, aggregateID string, domainType interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key(aggregateID, domainType)]; ok {
+		s.remove(el)
+	}
+	return nil
+}
+
+// Flush invalidates all the cached aggregates of the domain type,
+// or all the cached aggregates if domain type is nil.
+func (s *CacheService) Flush(ctx context.Context, domainType interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := fmt.Sprintf("%T:", domainType)
+	for k, el := range s.entries {
+		if domainType == nil || strings.HasPrefix(k, prefix) {
+			s.remove(el)
+		}
+	}
+	return nil
+}
+
+// remove removes the entry from the cache. It must be called with the cache locked.
+func (s *CacheService) remove(el *list.Element) {
+	e := s.lru.Remove(el).(*entry)
+	delete(s.entries, e.key)
+	s.size -= e.size
+}
+
+// Stats returns the cache statistics.
+func (s *CacheService) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Entries = s.lru.Len()
+	stats.Size = s.size
+	return stats
+}
+
+// DB returns nil as aggregates are held in memory
+func (s *CacheService) DB() interface{} {
+	return nil
+}
@@ -0,0 +1,131 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/memory"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/paulormart/assert"
+)
+
+// Ensure aggregates are deep copied in and out of the cache.
+func TestCacheService_SetGet(t *testing.T) {
+	c := memory.NewCacheService(memory.Config{})
+
+	user := &pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+	in := &hippo.Aggregate{
+		State:   user,
+		Version: 1,
+	}
+
+	ctx := context.Background()
+	if err := c.Set(ctx, user.GetId(), in); err != nil {
+		t.Fatal(err)
+	}
+	// Changes after set do not change the cached state.
+	user.Name = "changed"
+
+	out := &hippo.Aggregate{
+		State: &pb.User{},
+	}
+	if err := c.Get(ctx, user.GetId(), out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), out.Version)
+	assert.Equal(t, "test", out.State.(*pb.User).GetName())
+
+	// Ensure aggregates are namespaced by domain type.
+	assert.Equal(t, hippo.ErrKeyDoesNotExist, c.Get(ctx, user.GetId(), &hippo.Aggregate{State: &empty.Empty{}}))
+
+	// Ensure older or equal versions do not overwrite newer ones.
+	if err := c.Set(ctx, user.GetId(), &hippo.Aggregate{State: &pb.User{Name: "older"}, Version: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, user.GetId(), &hippo.Aggregate{State: &pb.User{Name: "equal"}, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	out = &hippo.Aggregate{
+		State: &pb.User{},
+	}
+	if err := c.Get(ctx, user.GetId(), out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "test", out.State.(*pb.User).GetName())
+
+	assert.Equal(t, memory.Stats{Hits: 2, Misses: 1, Entries: 1, Size: c.Stats().Size}, c.Stats())
+}
+
+// Ensure least recently used aggregates are evicted.
+func TestCacheService_Evict(t *testing.T) {
+	c := memory.NewCacheService(memory.Config{MaxEntries: 2})
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b"} {
+		if err := c.Set(ctx, id, &hippo.Aggregate{State: &pb.User{Id: id}, Version: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Use a, so that b is the least recently used.
+	if err := c.Get(ctx, "a", &hippo.Aggregate{State: &pb.User{}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "c", &hippo.Aggregate{State: &pb.User{Id: "c"}, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, nil, c.Get(ctx, "a", &hippo.Aggregate{State: &pb.User{}}))
+	assert.Equal(t, hippo.ErrKeyDoesNotExist, c.Get(ctx, "b", &hippo.Aggregate{State: &pb.User{}}))
+	assert.Equal(t, nil, c.Get(ctx, "c", &hippo.Aggregate{State: &pb.User{}}))
+	assert.Equal(t, int64(1), c.Stats().Evictions)
+}
+
+// Ensure aggregates expire after the TTL.
+func TestCacheService_TTL(t *testing.T) {
+	c := memory.NewCacheService(memory.Config{TTL: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "a", &hippo.Aggregate{State: &pb.User{Id: "a"}, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, nil, c.Get(ctx, "a", &hippo.Aggregate{State: &pb.User{}}))
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, hippo.ErrKeyDoesNotExist, c.Get(ctx, "a", &hippo.Aggregate{State: &pb.User{}}))
+	assert.Equal(t, 0, c.Stats().Entries)
+}
+
+// account is a domain type that is not a proto message.
+type account struct {
+	ID      string `json:"id"`
+	Balance int64  `json:"balance"`
+}
+
+// Ensure states that are not proto messages are deep copied in and out of the cache.
+func TestCacheService_SetGetJSON(t *testing.T) {
+	c := memory.NewCacheService(memory.Config{})
+	ctx := context.Background()
+
+	in := &account{ID: "a", Balance: 10}
+	if err := c.Set(ctx, in.ID, &hippo.Aggregate{State: in, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	in.Balance = 20
+
+	out := &hippo.Aggregate{
+		State: &account{},
+	}
+	if err := c.Get(ctx, "a", out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), out.Version)
+	assert.Equal(t, &account{ID: "a", Balance: 10}, out.State)
+}
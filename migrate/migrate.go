@@ -0,0 +1,295 @@
+// Package migrate copies the events of a hippo event store to another one,
+// e.g. to move to another backend without downtime.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+	"github.com/golang/protobuf/proto"
+)
+
+// Config represents a configuration to initialize a new Migrator
+type Config struct {
+	// Name of the migration, the position of the migration is saved under it, required.
+	Name string
+
+	// Checkpoints persists the position of the migration, so that an interrupted
+	// migration resumes from the last copied event, defaults to in-memory checkpoints.
+	Checkpoints hippo.CheckpointService
+
+	// BatchSize number of events read from the source at once, defaults to 1000.
+	BatchSize int
+
+	// Interval between reads of the source while following new events, defaults to 1s.
+	Interval time.Duration
+
+	// Progress is called after every batch of copied events, defaults to log the progress.
+	Progress func(Progress)
+}
+
+// Progress represents the progress of a migration.
+type Progress struct {
+	// Events copied and skipped, since they were already in the destination.
+	Copied  int
+	Skipped int
+	// Position is the create time of the last event copied.
+	Position time.Time
+}
+
+// Mismatch represents an aggregate whose events differ between the source and destination.
+type Mismatch struct {
+	AggregateID         string
+	SourceEvents        int
+	DestinationEvents   int
+	SourceChecksum      string
+	DestinationChecksum string
+}
+
+// Migrator copies the events of a source store to a destination store. The source
+// event service must implement hippo.StreamService, so that events are copied in the
+// order they were created.
+type Migrator struct {
+	src  hippo.StoreService
+	dst  hippo.StoreService
+	conf Config
+
+	// last version of the aggregates in the destination
+	versions map[string]int64
+	progress Progress
+
+	// aggregates read from the source, including the ones read before
+	// the checkpoint the migration resumed from
+	aggregates map[string]bool
+	scanned    bool
+}
+
+// NewMigrator creates a new Migrator
+func NewMigrator(src, dst hippo.StoreService, conf Config) *Migrator {
+	if conf.Checkpoints == nil {
+		conf.Checkpoints = hippo.NewCheckpoints()
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 1000
+	}
+	if conf.Interval <= 0 {
+		conf.Interval = time.Second
+	}
+	if conf.Progress == nil {
+		conf.Progress = func(p Progress) {
+			log.Printf("migrate: %d events copied, %d skipped, position %s", p.Copied, p.Skipped, p.Position.Format(time.RFC3339Nano))
+		}
+	}
+	return &Migrator{
+		src:        src,
+		dst:        dst,
+		conf:       conf,
+		versions:   make(map[string]int64),
+		aggregates: make(map[string]bool),
+	}
+}
+
+// stream returns the source stream service.
+func (m *Migrator) stream() (hippo.StreamService, error) {
+	if m.conf.Name == "" {
+		return nil, hippo.ErrSubscriptionNameRequired
+	}
+	st, ok := m.src.EventService().(hippo.StreamService)
+	if !ok {
+		return nil, hippo.ErrStreamServiceNotConfigured
+	}
+	return st, nil
+}
+
+// Copy copies the events created in the source since the last checkpoint and
+// returns the progress of the migration.
+func (m *Migrator) Copy(ctx context.Context) (Progress, error) {
+	st, err := m.stream()
+	if err != nil {
+		return m.progress, err
+	}
+	position, err := m.conf.Checkpoints.Get(ctx, m.conf.Name)
+	if err != nil {
+		return m.progress, err
+	}
+	if !m.scanned {
+		if err := m.scan(ctx, st, position); err != nil {
+			return m.progress, err
+		}
+		m.scanned = true
+	}
+	cursor := hippo.NewCursor(st, position, m.conf.BatchSize)
+	for {
+		events, err := cursor.Next(ctx)
+		if err != nil {
+			return m.progress, err
+		}
+		if len(events) == 0 {
+			return m.progress, nil
+		}
+		for _, e := range events {
+			if err := m.copy(ctx, e); err != nil {
+				return m.progress, err
+			}
+		}
+		if err := m.conf.Checkpoints.Set(ctx, m.conf.Name, cursor.Position()); err != nil {
+			return m.progress, err
+		}
+		m.progress.Position = cursor.Position().Time
+		m.conf.Progress(m.progress)
+	}
+}
+
+// scan records the aggregates of the events read before the position, so that
+// the aggregates copied before a migration resumed are verified too.
+func (m *Migrator) scan(ctx context.Context, st hippo.StreamService, position hippo.Position) error {
+	if position.IsZero() {
+		return nil
+	}
+	cursor := hippo.NewCursor(st, hippo.Position{}, m.conf.BatchSize)
+	for {
+		events, err := cursor.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		for _, e := range events {
+			if !position.Contains(e) {
+				return nil
+			}
+			m.aggregates[e.AggregateID] = true
+		}
+	}
+}
+
+// copy creates the event in the destination unless it is already there. Versions
+// must be continuous, so that no event is lost in the destination.
+func (m *Migrator) copy(ctx context.Context, e *hippo.Event) error {
+	m.aggregates[e.AggregateID] = true
+	v, ok := m.versions[e.AggregateID]
+	if !ok {
+		var err error
+		if v, err = m.dst.EventService().GetLastVersion(ctx, e.AggregateID); err != nil {
+			return err
+		}
+		m.versions[e.AggregateID] = v
+	}
+	switch {
+	case e.Version <= v:
+		m.progress.Skipped++
+		return nil
+	case e.Version != v+1:
+		return hippo.ErrVersionNotContinuous
+	}
+	if err := m.dst.EventService().Create(ctx, e); err != nil {
+		return err
+	}
+	m.versions[e.AggregateID] = e.Version
+	m.progress.Copied++
+	return nil
+}
+
+// Follow copies the events created in the source until the context is done,
+// e.g. until the services are switched to the destination.
+func (m *Migrator) Follow(ctx context.Context) error {
+	ticker := time.NewTicker(m.conf.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := m.Copy(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Verify compares the events of the aggregates in the source and destination
+// up to the last version copied and returns the aggregates whose event counts or
+// checksums differ. Events created in the source since they were copied are not
+// compared, so that the source is verified while it is still in use.
+func (m *Migrator) Verify(ctx context.Context, aggregateIDs ...string) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	for _, id := range aggregateIDs {
+		v, ok := m.versions[id]
+		if !ok {
+			var err error
+			if v, err = m.dst.EventService().GetLastVersion(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+		// Aggregates without events copied have nothing to compare.
+		if v == 0 {
+			continue
+		}
+		p := hippo.Params{ID: id, ToVersion: v}
+		src, err := m.src.EventService().List(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		dst, err := m.dst.EventService().List(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		srcSum, err := Checksum(src)
+		if err != nil {
+			return nil, err
+		}
+		dstSum, err := Checksum(dst)
+		if err != nil {
+			return nil, err
+		}
+		if len(src) != len(dst) || srcSum != dstSum {
+			mismatches = append(mismatches, Mismatch{
+				AggregateID:         id,
+				SourceEvents:        len(src),
+				DestinationEvents:   len(dst),
+				SourceChecksum:      srcSum,
+				DestinationChecksum: dstSum,
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+// Aggregates returns the sorted IDs of the aggregates read from the source,
+// including the ones already in the destination and the ones copied before
+// the migration resumed from its checkpoint.
+func (m *Migrator) Aggregates() []string {
+	ids := make([]string, 0, len(m.aggregates))
+	for id := range m.aggregates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Checksum returns the SHA-256 checksum of the events, encoded deterministically
+// in the wire format.
+func Checksum(events []*hippo.Event) (string, error) {
+	h := sha256.New()
+	buf := proto.NewBuffer(nil)
+	buf.SetDeterministic(true)
+	for _, e := range events {
+		pb, err := internal.EncodeEvent(e)
+		if err != nil {
+			return "", err
+		}
+		buf.Reset()
+		if err := buf.Marshal(pb); err != nil {
+			return "", err
+		}
+		h.Write(buf.Bytes())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
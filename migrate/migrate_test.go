@@ -0,0 +1,110 @@
+package migrate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/migrate"
+	"github.com/aukbit/hippo/mock"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/paulormart/assert"
+)
+
+// MustOpenStore returns a mock store holding the events in memory.
+func MustOpenStore(events ...*hippo.Event) (*mock.StoreService, func(...*hippo.Event)) {
+	store := mock.NewEvents(events...)
+	return store.StoreService(), store.Add
+}
+
+// newEvent returns an event of the aggregate with the version.
+func newEvent(aggregateID string, version int64) *hippo.Event {
+	e := hippo.NewEventProto("user_created", aggregateID, &pb.User{Id: aggregateID, Name: "Mando"})
+	e.Version = version
+	e.Metadata = map[string]string{"a": "1", "b": "2", "c": "3"}
+	time.Sleep(time.Microsecond)
+	return e
+}
+
+func TestMigrator_Copy(t *testing.T) {
+	src, add := MustOpenStore(newEvent("a", 1), newEvent("b", 1), newEvent("a", 2))
+	// Destination already holds an event, as if the migration was interrupted.
+	dst, _ := MustOpenStore(newEvent("a", 1))
+	ctx := context.Background()
+	checkpoints := hippo.NewCheckpoints()
+
+	var reports []migrate.Progress
+	m := migrate.NewMigrator(src, dst, migrate.Config{
+		Name:        "influx-to-grpc",
+		Checkpoints: checkpoints,
+		BatchSize:   2,
+		Progress:    func(p migrate.Progress) { reports = append(reports, p) },
+	})
+	p, err := m.Copy(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, p.Copied)
+	assert.Equal(t, 1, p.Skipped)
+	assert.Equal(t, 2, len(reports))
+
+	// Aggregate a differs since its first event was created separately.
+	mismatches, err := m.Verify(ctx, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(mismatches))
+	assert.Equal(t, "a", mismatches[0].AggregateID)
+	assert.Equal(t, 2, mismatches[0].DestinationEvents)
+
+	// New events in the source are copied from the checkpoint.
+	add(newEvent("b", 2))
+	m = migrate.NewMigrator(src, dst, migrate.Config{Name: "influx-to-grpc", Checkpoints: checkpoints})
+	p, err = m.Copy(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, p.Copied)
+	assert.Equal(t, 0, p.Skipped)
+	// Aggregates copied before resuming are verified too.
+	assert.Equal(t, []string{"a", "b"}, m.Aggregates())
+
+	// Events created in the source after the copy are not compared.
+	add(newEvent("b", 3))
+	mismatches, err = m.Verify(ctx, "b")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(mismatches))
+}
+
+func TestMigrator_VersionNotContinuous(t *testing.T) {
+	src, _ := MustOpenStore(newEvent("a", 1), newEvent("a", 3))
+	dst, _ := MustOpenStore()
+
+	m := migrate.NewMigrator(src, dst, migrate.Config{Name: "gap"})
+	p, err := m.Copy(context.Background())
+	assert.Equal(t, hippo.ErrVersionNotContinuous, err)
+	assert.Equal(t, 1, p.Copied)
+}
+
+func TestMigrator_Follow(t *testing.T) {
+	src, add := MustOpenStore(newEvent("a", 1))
+	dst, _ := MustOpenStore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	copied := make(chan int, 10)
+	m := migrate.NewMigrator(src, dst, migrate.Config{
+		Name:     "follow",
+		Interval: 10 * time.Millisecond,
+		Progress: func(p migrate.Progress) { copied <- p.Copied },
+	})
+	done := make(chan error)
+	go func() { done <- m.Follow(ctx) }()
+
+	assert.Equal(t, 1, <-copied)
+	add(newEvent("a", 2))
+	assert.Equal(t, 2, <-copied)
+
+	cancel()
+	assert.Equal(t, nil, <-done)
+}
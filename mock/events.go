@@ -0,0 +1,142 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/aukbit/hippo"
+)
+
+// Events is an in-memory event store backing a mock EventService, so that tests
+// share the same behaviour of an event store.
+type Events struct {
+	mu     sync.Mutex
+	events []*hippo.Event
+
+	es EventService
+}
+
+// NewEvents returns an in-memory event store holding the events.
+func NewEvents(events ...*hippo.Event) *Events {
+	s := &Events{events: events}
+	s.es = EventService{
+		CreateFn:         s.create,
+		GetLastVersionFn: s.lastVersion,
+		ListFn:           s.list,
+		StreamFn:         s.stream,
+		AppendFn:         s.append,
+		AtomicFn:         func() bool { return true },
+	}
+	return s
+}
+
+// EventService returns the mock EventService backed by the events. Its functions
+// may be replaced, e.g. to inject failures.
+func (s *Events) EventService() *EventService {
+	return &s.es
+}
+
+// StoreService returns a mock StoreService of the EventService.
+func (s *Events) StoreService() *StoreService {
+	return &StoreService{EventServiceFn: s.EventService}
+}
+
+// Add adds the events without checking their versions, e.g. as a concurrent
+// writer would.
+func (s *Events) Add(events ...*hippo.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+}
+
+// All returns the events in the order they were added.
+func (s *Events) All() []*hippo.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*hippo.Event{}, s.events...)
+}
+
+// create adds the event if it follows the last version of its aggregate.
+func (s *Events) create(ctx context.Context, e *hippo.Event) error {
+	return s.append(ctx, []*hippo.Event{e})
+}
+
+// append adds the events of an aggregate if they follow its last version.
+func (s *Events) append(ctx context.Context, events []*hippo.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := events[0].AggregateID
+	v := s.version(id)
+	for _, e := range events {
+		if e.AggregateID != id {
+			return hippo.ErrInvalidEventFormat
+		}
+		if v++; e.Version != v {
+			return hippo.ErrConcurrencyException
+		}
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+// lastVersion returns the last version of the aggregate.
+func (s *Events) lastVersion(ctx context.Context, aggregateID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version(aggregateID), nil
+}
+
+// version returns the last version of the aggregate, it must be called with s locked.
+func (s *Events) version(aggregateID string) int64 {
+	var v int64
+	for _, e := range s.events {
+		if e.AggregateID == aggregateID && e.Version > v {
+			v = e.Version
+		}
+	}
+	return v
+}
+
+// list returns the events of the aggregate matching the parameters.
+func (s *Events) list(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+	if p.ID == "" {
+		return nil, hippo.ErrParamsIDRequired
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*hippo.Event
+	for _, e := range s.events {
+		if e.AggregateID != p.ID || e.Version < p.FromVersion {
+			continue
+		}
+		if p.ToVersion > 0 && e.Version > p.ToVersion {
+			continue
+		}
+		if p.Match(e) {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// stream returns the events created after the time, in creation order.
+func (s *Events) stream(ctx context.Context, p hippo.StreamParams) ([]*hippo.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*hippo.Event
+	for _, e := range s.events {
+		if e.CreateTime.After(p.After) {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreateTime.Before(out[j].CreateTime) })
+	if p.Limit > 0 && len(out) > p.Limit {
+		out = out[:p.Limit]
+	}
+	return out, nil
+}
@@ -15,6 +15,15 @@ type EventService struct {
 
 	ListFn      func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error)
 	ListInvoked bool
+
+	StreamFn      func(ctx context.Context, p hippo.StreamParams) ([]*hippo.Event, error)
+	StreamInvoked bool
+
+	AppendFn      func(ctx context.Context, events []*hippo.Event) error
+	AppendInvoked bool
+
+	AtomicFn      func() bool
+	AtomicInvoked bool
 }
 
 func (s *EventService) Create(ctx context.Context, e *hippo.Event) error {
@@ -29,6 +38,18 @@ func (s *EventService) List(ctx context.Context, p hippo.Params) ([]*hippo.Event
 	s.ListInvoked = true
 	return s.ListFn(ctx, p)
 }
+func (s *EventService) Stream(ctx context.Context, p hippo.StreamParams) ([]*hippo.Event, error) {
+	s.StreamInvoked = true
+	return s.StreamFn(ctx, p)
+}
+func (s *EventService) Append(ctx context.Context, events []*hippo.Event) error {
+	s.AppendInvoked = true
+	return s.AppendFn(ctx, events)
+}
+func (s *EventService) Atomic() bool {
+	s.AtomicInvoked = true
+	return s.AtomicFn()
+}
 
 type StoreService struct {
 	EventServiceFn      func() *EventService
@@ -41,10 +62,12 @@ func (s *StoreService) EventService() hippo.EventService {
 }
 
 type CacheService struct {
-	GetFn      func(ctx context.Context, aggregateID string, out *hippo.Aggregate) error
-	GetInvoked bool
-	SetFn      func(ctx context.Context, aggregateID string, in *hippo.Aggregate) error
-	SetInvoked bool
+	GetFn         func(ctx context.Context, aggregateID string, out *hippo.Aggregate) error
+	GetInvoked    bool
+	SetFn         func(ctx context.Context, aggregateID string, in *hippo.Aggregate) error
+	SetInvoked    bool
+	DeleteFn      func(ctx context.Context, aggregateID string, domainType interface{}) error
+	DeleteInvoked bool
 }
 
 func (s *CacheService) Get(ctx context.Context, aggregateID string, out *hippo.Aggregate) error {
@@ -57,6 +80,39 @@ func (s *CacheService) Set(ctx context.Context, aggregateID string, in *hippo.Ag
 	return s.SetFn(ctx, aggregateID, in)
 }
 
+func (s *CacheService) Delete(ctx context.Context, aggregateID string, domainType interface{}) error {
+	s.DeleteInvoked = true
+	return s.DeleteFn(ctx, aggregateID, domainType)
+}
+
 func (s *CacheService) DB() interface{} {
 	return nil
 }
+
+type OutboxService struct {
+	PendingFn      func(ctx context.Context, limit int) ([]*hippo.Event, error)
+	PendingInvoked bool
+
+	MarkPublishedFn      func(ctx context.Context, e *hippo.Event) error
+	MarkPublishedInvoked bool
+}
+
+func (s *OutboxService) Pending(ctx context.Context, limit int) ([]*hippo.Event, error) {
+	s.PendingInvoked = true
+	return s.PendingFn(ctx, limit)
+}
+
+func (s *OutboxService) MarkPublished(ctx context.Context, e *hippo.Event) error {
+	s.MarkPublishedInvoked = true
+	return s.MarkPublishedFn(ctx, e)
+}
+
+type Publisher struct {
+	PublishFn      func(ctx context.Context, e *hippo.Event) error
+	PublishInvoked bool
+}
+
+func (s *Publisher) Publish(ctx context.Context, e *hippo.Event) error {
+	s.PublishInvoked = true
+	return s.PublishFn(ctx, e)
+}
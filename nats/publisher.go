@@ -0,0 +1,76 @@
+package nats
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+)
+
+var _ hippo.Publisher = &Publisher{}
+
+// Conn represents a connection to NATS able to publish messages,
+// e.g. *nats.Conn from github.com/nats-io/nats.go.
+type Conn interface {
+	Publish(subject string, data []byte) error
+}
+
+// Publisher publishes events to NATS subjects.
+type Publisher struct {
+	// connection to NATS
+	conn Conn
+
+	// prefix of the subjects
+	prefix string
+}
+
+// Config represents a configuration to initialize a new Publisher
+type Config struct {
+	// Prefix of the subjects the events are published to, defaults to "hippo".
+	// Events are published to the subject <prefix>.<topic>, so that subscribers
+	// are able to use NATS wildcards, e.g. "hippo.>" for all events.
+	Prefix string
+}
+
+// NewPublisher creates a new Publisher
+func NewPublisher(conn Conn, conf Config) *Publisher {
+	if conf.Prefix == "" {
+		conf.Prefix = "hippo"
+	}
+	return &Publisher{
+		conn:   conn,
+		prefix: conf.Prefix,
+	}
+}
+
+// Subject returns the subject the events of the topic are published to.
+func (p *Publisher) Subject(topic string) string {
+	return p.prefix + "." + topic
+}
+
+// Publish encodes the event in the internal.Event protobuf envelope and
+// publishes it to the subject of the event topic.
+func (p *Publisher) Publish(ctx context.Context, e *hippo.Event) error {
+	start := time.Now()
+	data, err := internal.MarshalEvent(e)
+	if err != nil {
+		return err
+	}
+	subject := p.Subject(e.Topic)
+	if err := p.conn.Publish(subject, data); err != nil {
+		return err
+	}
+	log.Printf("nats: event %s with aggregate %s version %d published to %s - duration: %v", e.Topic, e.AggregateID, e.Version, subject, time.Now().Sub(start))
+	return nil
+}
+
+// Decode decodes an event from the data of a NATS message.
+func Decode(data []byte) (*hippo.Event, error) {
+	e := &hippo.Event{}
+	if err := internal.UnmarshalEvent(data, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,51 @@
+package nats_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/nats"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+)
+
+// Conn is a local stand-in for a NATS connection.
+type Conn struct {
+	subjects []string
+	messages [][]byte
+}
+
+// Publish records the published message.
+func (c *Conn) Publish(subject string, data []byte) error {
+	c.subjects = append(c.subjects, subject)
+	c.messages = append(c.messages, data)
+	return nil
+}
+
+// Ensure event is published to the topic subject and can be decoded.
+func TestPublisher_Publish(t *testing.T) {
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+	event := hippo.NewEventProto("user_created", user.GetId(), &user)
+	event.Version = 1
+
+	conn := &Conn{}
+	p := nats.NewPublisher(conn, nats.Config{})
+
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"hippo.user_created"}, conn.subjects)
+
+	if other, err := nats.Decode(conn.messages[0]); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(event, other) {
+		t.Fatalf("unexpected copy: %#v != %#v", event, other)
+	}
+}
@@ -0,0 +1,51 @@
+package hippo
+
+import "context"
+
+// Origin represents where an event comes from, e.g. the calling service and
+// its ip address or the browser of a user.
+type Origin struct {
+	// Name of the origin, e.g. service name.
+	Name string
+	// IP address of the origin.
+	IP string
+}
+
+// originKey is the context key of the origin.
+type originKey struct{}
+
+// NewOriginContext returns a new context that carries the origin. Dispatch stamps
+// the origin on every event dispatched with the context.
+func NewOriginContext(ctx context.Context, o Origin) context.Context {
+	return context.WithValue(ctx, originKey{}, o)
+}
+
+// OriginFromContext returns the origin carried by the context, if any.
+func OriginFromContext(ctx context.Context) (Origin, bool) {
+	o, ok := ctx.Value(originKey{}).(Origin)
+	return o, ok
+}
+
+// StampOrigin assigns the origin carried by the context to the event. The
+// origin of the context is set server side, so it overrides the origin fields
+// supplied by the client; the event keeps them only without an origin. Events
+// already signed are not stamped, since the signature covers their origin.
+func StampOrigin(ctx context.Context, e *Event) {
+	o, ok := OriginFromContext(ctx)
+	if !ok || e.Signature != "" {
+		return
+	}
+	e.OriginName = o.Name
+	e.OriginIP = o.IP
+}
+
+// Match reports whether the event matches the origin filters of the parameters.
+func (p Params) Match(e *Event) bool {
+	if p.OriginName != "" && p.OriginName != e.OriginName {
+		return false
+	}
+	if p.OriginIP != "" && p.OriginIP != e.OriginIP {
+		return false
+	}
+	return true
+}
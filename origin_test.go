@@ -0,0 +1,99 @@
+package hippo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/mock"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+)
+
+// Ensure dispatched events are stamped with the origin carried by the context.
+func TestClient_DispatchOrigin(t *testing.T) {
+	var ss mock.StoreService
+	var es mock.EventService
+	var events []*hippo.Event
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		events = append(events, e)
+		return nil
+	}
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return int64(len(events)), nil
+	}
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		var out []*hippo.Event
+		for _, e := range events {
+			if p.Match(e) {
+				out = append(out, e)
+			}
+		}
+		return out, nil
+	}
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(&ss)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	clt.RegisterSigner(hippo.NewSigner(nil))
+
+	id := rand.String(10)
+	user := pb.User{Id: id, Name: "Leia"}
+
+	// Without origin.
+	if _, err := clt.Dispatch(context.Background(), hippo.NewEventProto("user_created", id, &user), &user); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "", events[0].OriginName)
+
+	// With origin, fields already set are overridden.
+	ctx := hippo.NewOriginContext(context.Background(), hippo.Origin{Name: "billing", IP: "10.0.0.1"})
+	e := hippo.NewEventProto("user_updated", id, &user)
+	e.OriginIP = "10.0.0.2"
+	if _, err := clt.Dispatch(ctx, e, &user); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "billing", events[1].OriginName)
+	assert.Equal(t, "10.0.0.1", events[1].OriginIP)
+
+	// The origin is signed.
+	assert.Equal(t, nil, clt.Verify(ctx, id))
+	events[1].OriginName = "shipping"
+	assert.Equal(t, true, clt.Verify(ctx, id) != nil)
+	events[1].OriginName = "billing"
+
+	// Filter by origin.
+	out, err := ss.EventService().List(ctx, hippo.Params{ID: id, OriginName: "billing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(out))
+	assert.Equal(t, int64(2), out[0].Version)
+}
+
+// Ensure signed events keep their origin, which the signature covers.
+func TestStampOrigin_Signed(t *testing.T) {
+	ctx := hippo.NewOriginContext(context.Background(), hippo.Origin{Name: "billing", IP: "10.0.0.1"})
+
+	e := hippo.NewEvent("user_updated", rand.String(10))
+	e.OriginName = "shipping"
+	e.Signature = "signed"
+	hippo.StampOrigin(ctx, e)
+	assert.Equal(t, "shipping", e.OriginName)
+	assert.Equal(t, "", e.OriginIP)
+
+	e.Signature = ""
+	hippo.StampOrigin(ctx, e)
+	assert.Equal(t, "billing", e.OriginName)
+	assert.Equal(t, "10.0.0.1", e.OriginIP)
+}
@@ -0,0 +1,77 @@
+package hippo
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RelayOptions is a configurable object for relay func
+type RelayOptions struct {
+	// Interval (optional) between outbox polls, defaults to 1s.
+	Interval time.Duration
+	// Delay (optional) minimum age of a pending event before it is relayed, so that
+	// events still being dispatched are published by Dispatch itself, defaults to 5s.
+	Delay time.Duration
+	// BatchSize (optional) maximum number of events relayed per poll, defaults to 100.
+	BatchSize int
+}
+
+// Relay publishes the events left unpublished in the outbox and marks them as
+// published until the context is done. Together with Dispatch it guarantees
+// that every persisted event is published at least once.
+func (c *Client) Relay(ctx context.Context, opts RelayOptions) error {
+	if c.outbox == nil {
+		return ErrOutboxServiceNotConfigured
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Delay <= 0 {
+		opts.Delay = 5 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	t := time.NewTicker(opts.Interval)
+	defer t.Stop()
+	for {
+		if _, err := c.relay(ctx, opts); err != nil {
+			log.Printf("outbox: relay failed > error %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// relay publishes one batch of pending events and returns how many were published.
+func (c *Client) relay(ctx context.Context, opts RelayOptions) (int, error) {
+	start := time.Now()
+	events, err := c.outbox.Pending(ctx, opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, e := range events {
+		// Pending events are ordered by create time, the remaining ones are too recent.
+		if time.Since(e.CreateTime) < opts.Delay {
+			break
+		}
+		if err := c.publisher.Publish(ctx, e); err != nil {
+			return n, err
+		}
+		if err := c.outbox.MarkPublished(ctx, e); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if n > 0 {
+		log.Printf("outbox: %d events relayed - duration: %v", n, time.Now().Sub(start))
+	}
+	return n, nil
+}
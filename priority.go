@@ -0,0 +1,82 @@
+package hippo
+
+// priorityQueue holds the events received by a worker not yet processed, so that
+// higher priority events, where 0 is the highest priority, are processed ahead of
+// lower priority events. The events of an aggregate are kept in the order they
+// were received, an aggregate with a queued higher priority event takes that
+// priority for all its queued events so that the event is not held behind them.
+// Aggregates with the same priority are processed in the order they were received.
+type priorityQueue struct {
+	queues map[string][]queued
+	n      int
+	seq    int64
+}
+
+// queued represents an event in the queue with its order of arrival.
+type queued struct {
+	e   *Event
+	seq int64
+}
+
+// newPriorityQueue returns a new, empty, priorityQueue.
+func newPriorityQueue() *priorityQueue {
+	return &priorityQueue{queues: make(map[string][]queued)}
+}
+
+// len returns the number of queued events.
+func (q *priorityQueue) len() int {
+	return q.n
+}
+
+// push queues the event.
+func (q *priorityQueue) push(e *Event) {
+	q.seq++
+	q.queues[e.AggregateID] = append(q.queues[e.AggregateID], queued{e: e, seq: q.seq})
+	q.n++
+}
+
+// pop removes and returns the next event to process, or nil if the queue is empty.
+func (q *priorityQueue) pop() *Event {
+	var next string
+	var priority int32
+	var seq int64
+	for id, events := range q.queues {
+		// The priority of the aggregate is the highest of its queued events.
+		p := events[0].e.Priority
+		for _, ev := range events[1:] {
+			if ev.e.Priority < p {
+				p = ev.e.Priority
+			}
+		}
+		if seq == 0 || p < priority || (p == priority && events[0].seq < seq) {
+			next, priority, seq = id, p, events[0].seq
+		}
+	}
+	if seq == 0 {
+		return nil
+	}
+
+	events := q.queues[next]
+	e := events[0].e
+	if len(events) == 1 {
+		delete(q.queues, next)
+	} else {
+		q.queues[next] = events[1:]
+	}
+	q.n--
+	return e
+}
+
+// fill queues the events available in the channel without blocking, while the
+// queue holds less than max events. Publishers are still blocked once both the
+// channel buffer and the queue are full.
+func (q *priorityQueue) fill(c chan *Event, max int) {
+	for q.n < max {
+		select {
+		case e := <-c:
+			q.push(e)
+		default:
+			return
+		}
+	}
+}
@@ -2,9 +2,13 @@ package hippo
 
 import (
 	"context"
+	"hash/fnv"
 	"log"
 	"os"
 	"os/signal"
+	"path"
+	"sort"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -16,11 +20,68 @@ type Topic string
 // ActionFn signature of an action func
 type ActionFn func(context.Context, *Event) error
 
-// ActionTopics map between a topic and respective action func
+// ActionTopics map between a topic and respective action func.
+// A topic may also be a pattern, e.g. user_* or * for all topics,
+// with the syntax of path.Match.
 type ActionTopics map[Topic][]ActionFn
 
+// Actions returns the actions of the exact topic followed by the actions of
+// the matching topic patterns, sorted by pattern.
+func (at ActionTopics) Actions(t Topic) []ActionFn {
+	actions := append([]ActionFn{}, at[t]...)
+
+	var patterns []string
+	for p := range at {
+		if p.isPattern() && p.match(t) {
+			patterns = append(patterns, string(p))
+		}
+	}
+	sort.Strings(patterns)
+	for _, p := range patterns {
+		actions = append(actions, at[Topic(p)]...)
+	}
+	return actions
+}
+
+// FilterFn represents a predicate on the events to be relayed to a subscriber.
+type FilterFn func(*Event) bool
+
+// MatchSchema returns a filter for events with the schema, which may be a pattern
+// with the syntax of path.Match, e.g. *.User.
+func MatchSchema(schema string) FilterFn {
+	return func(e *Event) bool {
+		ok, _ := path.Match(schema, e.Schema)
+		return ok
+	}
+}
+
+// isPattern reports whether the topic contains any of the path.Match special characters.
+func (t Topic) isPattern() bool {
+	return strings.ContainsAny(string(t), `*?[\`)
+}
+
+// match reports whether the topic, or topic pattern, matches the event topic.
+func (t Topic) match(topic Topic) bool {
+	if !t.isPattern() {
+		return t == topic
+	}
+	ok, _ := path.Match(string(t), string(topic))
+	return ok
+}
+
+type filter struct {
+	fn      FilterFn
+	actions []ActionFn
+}
+
 type handler struct {
-	topics ActionTopics
+	topics  ActionTopics
+	filters []filter
+	// overflow is called instead of blocking when the channel is full, optional
+	overflow func(*Event)
+	// done is closed when the channel is unsubscribed, releasing the publishers
+	// blocked sending to it
+	done chan struct{}
 }
 
 func (h *handler) valid(t Topic) bool {
@@ -44,6 +105,37 @@ func (h *handler) clear(t Topic) {
 	delete(h.topics, t)
 }
 
+// match reports whether any topic, topic pattern or filter of the handler matches the event.
+func (h *handler) match(e *Event) bool {
+	if h.valid(e.GetTopic()) {
+		return true
+	}
+	for t := range h.topics {
+		if t.match(e.GetTopic()) {
+			return true
+		}
+	}
+	for _, f := range h.filters {
+		if f.fn(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// actions returns the actions of the topic and topic patterns matching the
+// event followed by the actions of the matching filters.
+func (h *handler) actions(e *Event) []ActionFn {
+	actions := h.topics.Actions(e.GetTopic())
+
+	for _, f := range h.filters {
+		if f.fn(e) {
+			actions = append(actions, f.actions...)
+		}
+	}
+	return actions
+}
+
 // handlers is a collection of events.
 var handlers struct {
 	sync.Mutex
@@ -55,7 +147,7 @@ var handlers struct {
 // If no events are provided, all incoming events will be relayed to c.
 // Otherwise, just the provided events will.
 //
-// Package pubsub will block sending to c:
+// Package pubsub will block sending to c until c is unsubscribed:
 // For a channel used for notification of just one event value,
 // a buffer of size 1 is sufficient.
 //
@@ -69,6 +161,10 @@ var handlers struct {
 // It is allowed to call Subscribe multiple times with different channels
 // and the same events: each channel receives copies of incoming
 // events independently.
+//
+// Topics may be patterns, e.g. user_* or * for all events: each pattern is
+// referenced as a topic of its own and its actions run after the actions of
+// the exact topic.
 func Subscribe(c chan *Event, topics ActionTopics) {
 	start := time.Now()
 	if c == nil {
@@ -78,22 +174,16 @@ func Subscribe(c chan *Event, topics ActionTopics) {
 	handlers.Lock()
 	defer handlers.Unlock()
 
-	h, ok := handlers.m[c]
-	if !ok {
-		if handlers.m == nil {
-			handlers.m = make(map[chan *Event]*handler)
-		}
-		if handlers.ref == nil {
-			handlers.ref = make(map[Topic]int64)
-		}
-		h = &handler{make(ActionTopics)}
-		handlers.m[c] = h
-	}
+	h := subscriber(c)
 
 	add := func(t Topic, a []ActionFn) {
 		if t == "" {
 			return
 		}
+		if _, err := path.Match(string(t), ""); err != nil {
+			log.Printf("pubsub: channel %v with topic %v not subscribed > error %v", c, t, err)
+			return
+		}
 		if !h.valid(t) {
 			h.set(t, a)
 			handlers.ref[t]++
@@ -106,6 +196,95 @@ func Subscribe(c chan *Event, topics ActionTopics) {
 	}
 }
 
+// SubscribeFilter causes package pubsub to relay incoming events that satisfy
+// the filter to c and run the respective actions on them.
+//
+// It is allowed to call SubscribeFilter multiple times with the same channel:
+// each call adds a filter to that channel.
+func SubscribeFilter(c chan *Event, fn FilterFn, actions []ActionFn) {
+	if c == nil {
+		panic("pubsub: subscribe using nil channel")
+	}
+	if fn == nil {
+		return
+	}
+
+	handlers.Lock()
+	defer handlers.Unlock()
+
+	h := subscriber(c)
+	h.filters = append(h.filters, filter{fn: fn, actions: actions})
+	log.Printf("pubsub: channel %v with filter subscribed", c)
+}
+
+// OnOverflow causes package pubsub to never block sending to c: once the buffer
+// of c is full, the event is not sent to c and fn is called instead, e.g. to
+// disconnect a slow remote subscriber. fn must not block.
+func OnOverflow(c chan *Event, fn func(*Event)) {
+	if c == nil {
+		panic("pubsub: subscribe using nil channel")
+	}
+
+	handlers.Lock()
+	defer handlers.Unlock()
+
+	subscriber(c).overflow = fn
+}
+
+// subscriber returns the handler of the channel, creating one if the channel is
+// not yet subscribed. It must be called with handlers locked.
+func subscriber(c chan *Event) *handler {
+	h, ok := handlers.m[c]
+	if !ok {
+		if handlers.m == nil {
+			handlers.m = make(map[chan *Event]*handler)
+		}
+		if handlers.ref == nil {
+			handlers.ref = make(map[Topic]int64)
+		}
+		h = &handler{topics: make(ActionTopics), done: make(chan struct{})}
+		handlers.m[c] = h
+	}
+	return h
+}
+
+// Publisher represents a service for publishing events to subscribers.
+type Publisher interface {
+	Publish(ctx context.Context, e *Event) error
+}
+
+// Ensure Bus implements Publisher.
+var _ Publisher = Bus{}
+
+// Bus is the in-process Publisher that relays events to the subscribed channels.
+type Bus struct{}
+
+// Publish publishes an event on the registered subscriber channels.
+func (Bus) Publish(ctx context.Context, e *Event) error {
+	publish(e)
+	return nil
+}
+
+// multiPublisher publishes events to several publishers.
+type multiPublisher []Publisher
+
+// MultiPublisher returns a publisher that publishes events to all the given
+// publishers, e.g. the in-process bus and a message broker.
+func MultiPublisher(publishers ...Publisher) Publisher {
+	return multiPublisher(publishers)
+}
+
+// Publish publishes the event to every publisher and returns the first error.
+func (m multiPublisher) Publish(ctx context.Context, e *Event) error {
+	var err error
+	for _, p := range m {
+		if perr := p.Publish(ctx, e); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	return err
+}
+
 // Publish publishes an event on the registered subscriber channels.
 func publish(e *Event) {
 	start := time.Now()
@@ -113,13 +292,35 @@ func publish(e *Event) {
 		return
 	}
 
+	// Collect subscribed channels first so that workers are able to
+	// access handlers while publish is blocked sending to them.
+	type subscription struct {
+		c        chan *Event
+		done     chan struct{}
+		overflow func(*Event)
+	}
 	handlers.Lock()
-	defer handlers.Unlock()
-
+	var subscriptions []subscription
 	for c, h := range handlers.m {
-		if h.valid(Topic(e.Topic)) {
-			// NOTE: block sending to c if buffer is full
-			c <- e
+		if h.match(e) {
+			subscriptions = append(subscriptions, subscription{c: c, done: h.done, overflow: h.overflow})
+		}
+	}
+	handlers.Unlock()
+
+	for _, s := range subscriptions {
+		if s.overflow == nil {
+			// NOTE: block sending to c if buffer is full, until c is unsubscribed
+			select {
+			case s.c <- e:
+			case <-s.done:
+			}
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			s.overflow(e)
 		}
 	}
 	log.Printf("pubsub: event %s with aggregate %s version %d published - duration: %v", e.Topic, e.AggregateID, e.Version, time.Now().Sub(start))
@@ -152,41 +353,136 @@ func Unsubscribe(c chan *Event) {
 	for t := range h.topics {
 		remove(t)
 	}
+	h.filters = nil
+	close(h.done)
 
 	delete(handlers.m, c)
 }
 
-// Worker waits for events from a subscribed channel and run respective action functions
+// WorkerOptions is a configurable object for worker func
+type WorkerOptions struct {
+	// Retry (optional) policy applied to every failed action of the subscription.
+	// Actions wrapped with WithRetry use their own policy instead.
+	Retry *RetryPolicy
+	// DeadLetter (optional) receives the events whose actions exhausted all retries.
+	DeadLetter DeadLetterService
+	// PoolSize (optional) number of goroutines processing events in parallel, defaults to 1.
+	// Events are partitioned by aggregate ID, so that the events of the same aggregate
+	// are always processed in order by the same goroutine.
+	PoolSize int
+}
+
+// Worker waits for events from a subscribed channel and run respective action functions.
+// Events waiting in the channel are processed by priority, where 0 is the highest
+// priority, while the events of each aggregate are processed in order.
 func Worker(ctx context.Context, c chan *Event) {
+	WorkerWithOptions(ctx, c, WorkerOptions{})
+}
+
+// WorkerWithOptions waits for events from a subscribed channel and run respective
+// action functions, retrying and dead-lettering failed actions as configured.
+func WorkerWithOptions(ctx context.Context, c chan *Event, opts WorkerOptions) {
 	if c == nil {
 		panic("pubsub: subscribe using nil channel")
 	}
 
+	handlers.Lock()
 	h, ok := handlers.m[c]
+	handlers.Unlock()
 	if !ok {
 		return
 	}
 
+	if opts.PoolSize <= 1 {
+		loop(ctx, c, true, func(e *Event) {
+			opts.handle(ctx, h, e)
+		})
+		return
+	}
+
+	// Launch a goroutine per partition.
+	wg := &sync.WaitGroup{}
+	partitions := make([]chan *Event, opts.PoolSize)
+	for i := range partitions {
+		partitions[i] = make(chan *Event, 1)
+		wg.Add(1)
+		go func(p chan *Event) {
+			defer wg.Done()
+			for e := range p {
+				opts.handle(ctx, h, e)
+			}
+		}(partitions[i])
+	}
+
+	loop(ctx, c, true, func(e *Event) {
+		// NOTE: block sending to the partition if its buffer is full
+		partitions[partition(e.AggregateID, opts.PoolSize)] <- e
+	})
+
+	// Wait for the events already partitioned to be processed.
+	for _, p := range partitions {
+		close(p)
+	}
+	wg.Wait()
+}
+
+// partition returns the index of the partition that processes the aggregate events.
+func partition(aggregateID string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(aggregateID))
+	return int(h.Sum32() % uint32(n))
+}
+
+// loop waits for events from a subscribed channel and calls fn for each one
+// until the context is done or a host signal is received, then unsubscribes the
+// channel so that publishers do not block on it. If prioritize is set, the events
+// available in the channel are queued and higher priority events are processed
+// first, keeping the order of the events of each aggregate.
+func loop(ctx context.Context, c chan *Event, prioritize bool, fn func(*Event)) {
 	//  Stop also in case of any host signal
 	sigch := make(chan os.Signal, 1)
 	signal.Notify(sigch, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sigch)
+
+	// Unsubscribe, which releases the publishers blocked sending to the
+	// channel, and discard the events left in it.
+	defer func() {
+		Unsubscribe(c)
+		drain(c, nil)
+	}()
+
+	// Queue up to as many events as the channel buffer holds.
+	q := newPriorityQueue()
+	max := cap(c)
+	if max < 1 {
+		max = 1
+	}
 outer:
 	for {
+		if prioritize {
+			q.fill(c, max)
+		}
+		if q.len() > 0 {
+			select {
+			case <-sigch:
+				break outer
+			case <-ctx.Done():
+				break outer
+			default:
+				fn(q.pop())
+				continue
+			}
+		}
 		select {
 		case e := <-c:
-			actions := h.get(e.GetTopic())
-			for i, a := range actions {
-				start := time.Now()
-				err := a(ctx, e)
-				if err != nil {
-					// TODO: retry running the func in an exponential way
-					log.Printf("pubsub: action %v failed for event %v with aggregate %s version %d - duration: %v > error %v", i, e.Topic, e.AggregateID, e.Version, time.Now().Sub(start), err)
-					continue
-				}
-				log.Printf("pubsub: event %s with aggregate %s version %d action %v finished - duration: %v", e.Topic, e.AggregateID, e.Version, i, time.Now().Sub(start))
+			if prioritize {
+				q.push(e)
+				continue
 			}
+			fn(e)
 		case <-sigch:
-			Unsubscribe(c)
+			break outer
+		case <-ctx.Done():
 			break outer
 		default:
 			// keep on looping, non-blocking channel operations
@@ -195,3 +491,41 @@ outer:
 		}
 	}
 }
+
+// handle runs all the actions subscribed to the event topic, topic patterns and filters.
+func (o WorkerOptions) handle(ctx context.Context, h *handler, e *Event) {
+	handlers.Lock()
+	actions := h.actions(e)
+	handlers.Unlock()
+	for i, a := range actions {
+		o.run(ctx, i, a, e)
+	}
+}
+
+// run runs action i for the event, retrying it according to the retry policy.
+// If all attempts fail the event is sent to the dead letter service.
+func (o WorkerOptions) run(ctx context.Context, i int, a ActionFn, e *Event) {
+	start := time.Now()
+	p := RetryPolicy{MaxAttempts: 1}
+	if o.Retry != nil {
+		p = *o.Retry
+	}
+	n, err := p.run(ctx, e, a)
+	if err != nil {
+		log.Printf("pubsub: action %v failed for event %v with aggregate %s version %d after %d attempts - duration: %v > error %v", i, e.Topic, e.AggregateID, e.Version, n, time.Now().Sub(start), err)
+		if o.DeadLetter == nil {
+			return
+		}
+		d := &DeadLetter{
+			Event:    e,
+			Action:   a,
+			Attempts: n,
+			Err:      err,
+		}
+		if err := o.DeadLetter.Put(ctx, d); err != nil {
+			log.Printf("pubsub: event %s with aggregate %s version %d could not be dead-lettered > error %v", e.Topic, e.AggregateID, e.Version, err)
+		}
+		return
+	}
+	log.Printf("pubsub: event %s with aggregate %s version %d action %v finished - duration: %v", e.Topic, e.AggregateID, e.Version, i, time.Now().Sub(start))
+}
@@ -2,9 +2,11 @@ package hippo
 
 import (
 	"context"
+	"errors"
 	"log"
 	"sync"
 	"testing"
+	"time"
 
 	pb "github.com/aukbit/hippo/test/proto"
 	"github.com/aukbit/rand"
@@ -121,13 +123,289 @@ func TestPubSub_Worker(t *testing.T) {
 	at := ActionTopics{"user_created": []ActionFn{a, b}, "user_updated": []ActionFn{c}}
 	Subscribe(c1, at)
 	// Launch worker
-	go Worker(context.Background(), c1)
+	stop := runWorker(c1, WorkerOptions{})
 	// Publish
 	publish(ev1)
 	publish(ev2)
 
 	wg.Wait()
-	// Unsubscribe
-	Unsubscribe(c1)
-	//
+	// Stopping the worker unsubscribes the channel
+	stop()
+	_, ok := handlers.m[c1]
+	assert.Equal(t, false, ok)
+}
+
+func TestPubSub_WorkerDeadLetter(t *testing.T) {
+	u1 := pb.User{
+		Id:    rand.String(10),
+		Name:  "Luke",
+		Email: "luke@email.com",
+	}
+	ev1 := NewEventProto("user_created", u1.GetId(), &u1)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(3)
+
+	c1 := make(chan *Event, 1)
+	fail := true
+	a := func(ctx context.Context, e *Event) error {
+		if fail {
+			defer wg.Done()
+			return errors.New("action failed")
+		}
+		return nil
+	}
+	Subscribe(c1, ActionTopics{"user_created": []ActionFn{a}})
+
+	dlq := NewDeadLetterQueue()
+	opts := WorkerOptions{
+		Retry:      &RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond},
+		DeadLetter: dlq,
+	}
+	// Launch worker
+	stop := runWorker(c1, opts)
+	defer stop()
+	// Publish
+	publish(ev1)
+	wg.Wait()
+
+	// Wait for the event to be dead-lettered
+	var letters []*DeadLetter
+	for i := 0; i < 100 && len(letters) == 0; i++ {
+		time.Sleep(time.Millisecond)
+		letters, _ = dlq.List(context.Background())
+	}
+	assert.Equal(t, 1, len(letters))
+	assert.Equal(t, 3, letters[0].Attempts)
+	assert.Equal(t, ev1, letters[0].Event)
+
+	// Replay dead letter
+	fail = false
+	if err := dlq.Replay(context.Background(), letters[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	letters, _ = dlq.List(context.Background())
+	assert.Equal(t, 0, len(letters))
+	assert.Equal(t, ErrDeadLetterNotFound, dlq.Discard(context.Background(), "unknown"))
+}
+
+func TestPubSub_PatternTopics(t *testing.T) {
+	u1 := pb.User{
+		Id:    rand.String(10),
+		Name:  "Luke",
+		Email: "luke@email.com",
+	}
+	ev1 := NewEventProto("user_created", u1.GetId(), &u1)
+	ev2 := NewEventProto("account_created", u1.GetId(), &u1)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(3)
+
+	mu := sync.Mutex{}
+	var calls []string
+	action := func(name string) ActionFn {
+		return func(ctx context.Context, e *Event) error {
+			defer wg.Done()
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name+":"+e.Topic)
+			return nil
+		}
+	}
+
+	c1 := make(chan *Event, 1)
+	Subscribe(c1, ActionTopics{"user_*": []ActionFn{action("user")}, "*": []ActionFn{action("all")}})
+	assert.Equal(t, int64(1), handlers.ref["user_*"])
+	assert.Equal(t, int64(1), handlers.ref["*"])
+	assert.Equal(t, int64(0), handlers.ref[ev1.GetTopic()])
+	SubscribeFilter(c1, MatchSchema("*.User"), []ActionFn{action("schema")})
+
+	// Launch worker
+	stop := runWorker(c1, WorkerOptions{})
+	// Publish
+	publish(ev1)
+	wg.Wait()
+
+	assert.Equal(t, []string{"all:user_created", "user:user_created", "schema:user_created"}, calls)
+
+	// Ensure events not matching any pattern are still relayed to "*".
+	wg.Add(2)
+	publish(ev2)
+	wg.Wait()
+	assert.Equal(t, 5, len(calls))
+
+	// Stopping the worker unsubscribes the channel
+	stop()
+	assert.Equal(t, int64(0), handlers.ref["user_*"])
+	assert.Equal(t, int64(0), handlers.ref["*"])
+	assert.Equal(t, 0, len(handlers.ref))
+}
+
+func TestPubSub_WorkerPool(t *testing.T) {
+	// Find two aggregates processed by different partitions.
+	a1, a2 := "A", "B"
+	for i := 0; partition(a1, 4) == partition(a2, 4); i++ {
+		a2 = rand.String(10)
+	}
+
+	var events []*Event
+	for v := 1; v <= 5; v++ {
+		for _, id := range []string{a1, a2} {
+			e := NewEvent("user_updated", id)
+			e.Version = int64(v)
+			events = append(events, e)
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(len(events))
+
+	mu := sync.Mutex{}
+	versions := make(map[string][]int64)
+	started := make(chan struct{})
+	a := func(ctx context.Context, e *Event) error {
+		defer wg.Done()
+		// First event of a1 only completes once a2 events are being processed in parallel.
+		if e.AggregateID == a1 && e.Version == 1 {
+			select {
+			case <-started:
+			case <-time.After(time.Second):
+				t.Error("aggregates not processed in parallel")
+			}
+		}
+		if e.AggregateID == a2 && e.Version == 1 {
+			close(started)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		versions[e.AggregateID] = append(versions[e.AggregateID], e.Version)
+		return nil
+	}
+
+	c1 := make(chan *Event, 1)
+	Subscribe(c1, ActionTopics{"user_updated": []ActionFn{a}})
+
+	// Launch worker pool
+	stop := runWorker(c1, WorkerOptions{PoolSize: 4})
+	defer stop()
+	// Publish
+	for _, e := range events {
+		publish(e)
+	}
+	wg.Wait()
+
+	// Ensure events of each aggregate were processed in version order.
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, versions[a1])
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, versions[a2])
+}
+
+func TestPubSub_WorkerPriority(t *testing.T) {
+	event := func(aggregateID string, version int64, priority int32) *Event {
+		e := NewEvent("user_updated", aggregateID)
+		e.Version = version
+		e.Priority = priority
+		return e
+	}
+	a, b, c := rand.String(10), rand.String(10), rand.String(10)
+	events := []*Event{
+		event(a, 1, 5),
+		event(b, 1, 5),
+		event(c, 1, 0),
+		event(a, 2, 5),
+		// b takes the highest priority so that b2 is not held behind b1.
+		event(b, 2, 0),
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(len(events))
+
+	var mu sync.Mutex
+	var processed []*Event
+	c1 := make(chan *Event, 10)
+	action := func(ctx context.Context, e *Event) error {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		processed = append(processed, e)
+		return nil
+	}
+	Subscribe(c1, ActionTopics{"user_updated": []ActionFn{action}})
+	defer Unsubscribe(c1)
+
+	// Publish before the worker is launched, so that all events are waiting.
+	for _, e := range events {
+		publish(e)
+	}
+	stop := runWorker(c1, WorkerOptions{})
+	defer stop()
+
+	wg.Wait()
+	// b1 is promoted by b2, c1 arrived before b2, a has the lowest priority.
+	assert.Equal(t, []*Event{events[1], events[2], events[4], events[0], events[3]}, processed)
+}
+
+// runWorker launches a worker and returns a func that stops it and waits for it to exit.
+func runWorker(c chan *Event, opts WorkerOptions) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		WorkerWithOptions(ctx, c, opts)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Ensure publish does not block on a full channel with an overflow func.
+func TestPubSub_OnOverflow(t *testing.T) {
+	id := rand.String(10)
+	c := make(chan *Event, 1)
+	Subscribe(c, ActionTopics{"user_updated": []ActionFn{}})
+	var dropped []int64
+	OnOverflow(c, func(e *Event) {
+		dropped = append(dropped, e.Version)
+	})
+	defer Unsubscribe(c)
+
+	for i := 1; i <= 3; i++ {
+		e := NewEvent("user_updated", id)
+		e.Version = int64(i)
+		publish(e)
+	}
+	assert.Equal(t, int64(1), (<-c).Version)
+	assert.Equal(t, []int64{2, 3}, dropped)
+}
+
+// Ensure publishers blocked sending to a channel are released once it is unsubscribed.
+func TestPubSub_UnsubscribeReleasesPublishers(t *testing.T) {
+	id := rand.String(10)
+	c := make(chan *Event, 1)
+	Subscribe(c, ActionTopics{"user_updated": []ActionFn{}})
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func(v int64) {
+			defer wg.Done()
+			e := NewEvent("user_updated", id)
+			e.Version = v
+			publish(e)
+		}(int64(i))
+	}
+	// Wait for the buffer to fill up with publishers blocked on it.
+	time.Sleep(50 * time.Millisecond)
+	Unsubscribe(c)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishers still blocked after unsubscribe")
+	}
 }
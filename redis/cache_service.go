@@ -3,7 +3,10 @@ package redis
 import (
 	"context"
 	"fmt"
+	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aukbit/hippo"
 	"github.com/go-redis/redis"
@@ -11,12 +14,29 @@ import (
 )
 
 var _ hippo.CacheService = &CacheService{}
+var _ hippo.CacheDeleter = &CacheService{}
 
 // CacheService holds aggregates in Redis.
 type CacheService struct {
+	// Services
+	checkpointService CheckpointService
+	headService       HeadService
+	publisher         Publisher
 
 	// client to connect to Redis
 	db *redis.Client
+
+	// prefix of the aggregate keys
+	prefix string
+
+	// schemaKeys includes the state schema in the aggregate keys
+	schemaKeys bool
+
+	// ttl of the aggregate keys
+	ttl time.Duration
+
+	// sliding refreshes the ttl on every read
+	sliding bool
 }
 
 // Config represents a configuration to initialize a new Redis
@@ -29,11 +49,37 @@ type Config struct {
 
 	// Database to be used in Redis, defaults to 0
 	Database int
+
+	// Stream is the Redis stream events are published to, defaults to "hippo:events"
+	Stream string
+
+	// StreamPerTopic publishes the events of each topic to its own stream
+	// named <Stream>:<topic>, optional.
+	StreamPerTopic bool
+
+	// Prefix namespaces the keys written by the cache, e.g. "hippo" stores the
+	// aggregates under "hippo:agg:<aggregateID>", optional.
+	Prefix string
+
+	// SchemaKeys includes the state schema in the aggregate keys, e.g.
+	// "hippo:agg:*user.User:<aggregateID>", so that each domain type has its own namespace, optional.
+	SchemaKeys bool
+
+	// TTL is the default expiry of the cached aggregates, optional.
+	// Aggregates never expire if not set.
+	TTL time.Duration
+
+	// SlidingExpiration resets the expiry of an aggregate every time it is read, optional.
+	SlidingExpiration bool
 }
 
 // NewCacheService creates a new CacheService
 func NewCacheService() *CacheService {
-	return &CacheService{}
+	s := &CacheService{}
+	s.checkpointService.cache = s
+	s.headService.cache = s
+	s.publisher.cache = s
+	return s
 }
 
 // Link connects and pings the Redis database.
@@ -52,6 +98,17 @@ func (s *CacheService) Link(conf Config) error {
 	clt := redis.NewClient(opt)
 	s.db = clt
 
+	if conf.Stream == "" {
+		conf.Stream = "hippo:events"
+	}
+	s.publisher.stream = conf.Stream
+	s.publisher.perTopic = conf.StreamPerTopic
+
+	s.prefix = conf.Prefix
+	s.schemaKeys = conf.SchemaKeys
+	s.ttl = conf.TTL
+	s.sliding = conf.SlidingExpiration
+
 	// Ping checks Redis status
 	cmd := s.db.Ping()
 	if cmd.Err() != nil {
@@ -69,12 +126,48 @@ func (s *CacheService) Close() error {
 	return nil
 }
 
+// namespace returns the namespace of the keys of the domain type,
+// empty if keys are not namespaced. Aggregates have their own segment under the
+// prefix, so that they are kept apart from the checkpoints and streams.
+func (s *CacheService) namespace(domainType interface{}) string {
+	var parts []string
+	if s.prefix != "" {
+		parts = append(parts, s.prefix, "agg")
+	}
+	if s.schemaKeys && domainType != nil {
+		parts = append(parts, fmt.Sprintf("%T", domainType))
+	}
+	return strings.Join(parts, ":")
+}
+
+// key returns the key of the aggregate with the given domain type.
+func (s *CacheService) key(aggregateID string, domainType interface{}) string {
+	if ns := s.namespace(domainType); ns != "" {
+		return ns + ":" + aggregateID
+	}
+	return aggregateID
+}
+
 // Get returns aggregate data for the respective aggregateID.
-// State is unmarshaled to it's original form.
+// State is unmarshaled to it's original form. Version and state are read
+// atomically in a single command, so they are always consistent with each other.
 func (s *CacheService) Get(ctx context.Context, aggregateID string, out *hippo.Aggregate) error {
 
-	// Get version and state fields from aggregate hash key in Redis.
-	cmd := s.db.HGetAll(aggregateID)
+	key := s.key(aggregateID, out.State)
+
+	// Get version and state fields from aggregate hash key in Redis,
+	// resetting its expiry in the same transaction if sliding.
+	var cmd *redis.StringStringMapCmd
+	if s.sliding && s.ttl > 0 {
+		pipe := s.db.TxPipeline()
+		cmd = pipe.HGetAll(key)
+		pipe.PExpire(key, s.ttl)
+		if _, err := pipe.Exec(); err != nil {
+			return err
+		}
+	} else {
+		cmd = s.db.HGetAll(key)
+	}
 	if cmd.Err() != nil {
 		return cmd.Err()
 	}
@@ -94,11 +187,15 @@ func (s *CacheService) Get(ctx context.Context, aggregateID string, out *hippo.A
 		}
 	}
 
+	// Ensure the cached state has the schema of the buffer.
+	if schema, ok := cmd.Val()["schema"]; ok && schema != fmt.Sprintf("%T", out.State) {
+		return hippo.ErrInvalidSchema
+	}
+
 	if s, ok := cmd.Val()["state"]; !ok {
 		return hippo.ErrStateFieldDoesNotExist
 	} else if s != "" {
-		// Decodes data string into a proto message
-		if err := proto.UnmarshalText(s, out.State.(proto.Message)); err != nil {
+		if err := decode(cmd.Val()["format"], s, out.State); err != nil {
 			return err
 		}
 	}
@@ -106,22 +203,127 @@ func (s *CacheService) Get(ctx context.Context, aggregateID string, out *hippo.A
 	return nil
 }
 
-// Set stores aggregate has a form of hash key in Redis. Aggregate State is marshaled into string.
+// decode decodes the cached state into out based on the format recorded in the hash.
+// Aggregates cached before the format was recorded are encoded in text format.
+func decode(format, state string, out interface{}) error {
+	if format == "" {
+		// Decodes data string into a proto message
+		return proto.UnmarshalText(state, out.(proto.Message))
+	}
+	f, err := strconv.ParseInt(format, 10, 32)
+	if err != nil {
+		return err
+	}
+	switch hippo.Format(f) {
+	case hippo.PROTOBUF:
+		return proto.Unmarshal([]byte(state), out.(proto.Message))
+	default:
+		return hippo.ErrNotImplemented
+	}
+}
+
+// setScript writes the aggregate hash only if the incoming version is greater
+// than the version stored, so that a slower writer never overwrites a newer aggregate.
+// Returns 1 if the aggregate was written and 0 otherwise.
+var setScript = redis.NewScript(`
+local v = redis.call("HGET", KEYS[1], "version")
+if v and tonumber(v) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("HMSET", KEYS[1], "version", ARGV[1], "schema", ARGV[2], "format", ARGV[3], "state", ARGV[4])
+if tonumber(ARGV[5]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[5])
+end
+return 1
+`)
+
+// Set stores aggregate has a form of hash key in Redis. Aggregate State is marshaled into
+// the protobuf binary format, recorded in the hash together with the state schema.
+// The aggregate is compared and set atomically, it is only stored if its version is greater
+// than the version already cached.
 func (s *CacheService) Set(ctx context.Context, aggregateID string, in *hippo.Aggregate) error {
+	_, err := s.set(ctx, aggregateID, in)
+	return err
+}
 
-	fields := make(map[string]interface{})
-	fields["version"] = strconv.FormatInt(in.Version, 10)
-	fields["schema"] = fmt.Sprintf("%T", in.State)
-	fields["state"] = proto.CompactTextString(in.State.(proto.Message))
+// set stores the aggregate the same as Set, reporting whether it was written.
+func (s *CacheService) set(ctx context.Context, aggregateID string, in *hippo.Aggregate) (bool, error) {
 
-	if cmd := s.db.HMSet(aggregateID, fields); cmd.Err() != nil {
-		return cmd.Err()
+	version := strconv.FormatInt(in.Version, 10)
+	schema := fmt.Sprintf("%T", in.State)
+	format := strconv.FormatInt(int64(hippo.PROTOBUF), 10)
+	state, err := proto.Marshal(in.State.(proto.Message))
+	if err != nil {
+		return false, err
 	}
 
+	ttl := strconv.FormatInt(int64(s.ttl/time.Millisecond), 10)
+
+	n, err := setScript.Run(s.db, []string{s.key(aggregateID, in.State)}, version, schema, format, state, ttl).Int64()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		log.Printf("redis: aggregate %s version %s not cached, a newer or equal version is already cached", aggregateID, version)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Delete invalidates the cached aggregate of the given domain type.
+func (s *CacheService) Delete(ctx context.Context, aggregateID string, domainType interface{}) error {
+	if cmd := s.db.Del(s.key(aggregateID, domainType)); cmd.Err() != nil {
+		return cmd.Err()
+	}
 	return nil
 }
 
+// Flush invalidates all the cached aggregates in the namespace of the domain type,
+// or in the namespace of the prefix if domain type is nil.
+func (s *CacheService) Flush(ctx context.Context, domainType interface{}) error {
+	ns := s.namespace(domainType)
+	if ns == "" {
+		return hippo.ErrCacheNamespaceRequired
+	}
+
+	var cursor uint64
+	for {
+		keys, next, err := s.db.Scan(cursor, escapePattern(ns)+":*", 100).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if cmd := s.db.Del(keys...); cmd.Err() != nil {
+				return cmd.Err()
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// patternReplacer escapes the glob-style metacharacters of Redis patterns.
+var patternReplacer = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// escapePattern returns s escaped to match literally in a Redis pattern,
+// e.g. the schema "*user.User" must not match "*admin_user.User".
+func escapePattern(s string) string {
+	return patternReplacer.Replace(s)
+}
+
 // DB returns db connection
 func (s *CacheService) DB() interface{} {
 	return s.db
 }
+
+// CheckpointService returns the checkpoint service associated with the client.
+func (s *CacheService) CheckpointService() hippo.CheckpointService { return &s.checkpointService }
+
+// HeadService returns the head service associated with the client.
+func (s *CacheService) HeadService() hippo.HeadService { return &s.headService }
+
+// Publisher returns the Redis Streams publisher associated with the client.
+func (s *CacheService) Publisher() hippo.Publisher { return &s.publisher }
@@ -2,12 +2,18 @@ package redis_test
 
 import (
 	"context"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aukbit/hippo"
 	"github.com/aukbit/hippo/redis"
 	pb "github.com/aukbit/hippo/test/proto"
 	"github.com/aukbit/rand"
+	goredis "github.com/go-redis/redis"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/paulormart/assert"
 )
 
@@ -107,3 +113,211 @@ func TestCacheService_Get(t *testing.T) {
 	// assert.Equal(t, in, out)
 
 }
+
+// Ensure an older aggregate never overwrites a newer one.
+func TestCacheService_SetOlderVersion(t *testing.T) {
+	c := MustLinkCache()
+	defer c.Close()
+
+	id := rand.String(10)
+	ctx := context.Background()
+
+	newer := &hippo.Aggregate{
+		State:   &pb.User{Id: id, Name: "newer"},
+		Version: 2,
+	}
+	older := &hippo.Aggregate{
+		State:   &pb.User{Id: id, Name: "older"},
+		Version: 1,
+	}
+
+	// Set newer and then older aggregate in cache.
+	if err := c.Set(ctx, id, newer); err != nil {
+		t.Fatal(err)
+	} else if err := c.Set(ctx, id, older); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &hippo.Aggregate{
+		State: &pb.User{},
+	}
+	if err := c.Get(ctx, id, out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(2), out.Version)
+	assert.Equal(t, "newer", out.State.(*pb.User).GetName())
+}
+
+// Ensure concurrent writers leave the newest aggregate in cache.
+func TestCacheService_SetConcurrent(t *testing.T) {
+	c := MustLinkCache()
+	defer c.Close()
+
+	id := rand.String(10)
+	ctx := context.Background()
+
+	wg := &sync.WaitGroup{}
+	for v := 1; v <= 20; v++ {
+		wg.Add(1)
+		go func(v int) {
+			defer wg.Done()
+			in := &hippo.Aggregate{
+				State:   &pb.User{Id: id, Name: strconv.Itoa(v)},
+				Version: int64(v),
+			}
+			if err := c.Set(ctx, id, in); err != nil {
+				t.Error(err)
+			}
+		}(v)
+	}
+	wg.Wait()
+
+	out := &hippo.Aggregate{
+		State: &pb.User{},
+	}
+	if err := c.Get(ctx, id, out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(20), out.Version)
+	assert.Equal(t, "20", out.State.(*pb.User).GetName())
+}
+
+// Ensure aggregates are namespaced, expire and can be invalidated.
+func TestCacheService_Namespace(t *testing.T) {
+	c := NewCache()
+	prefix := rand.StringPrefix(10, "hippo:test:")
+	if err := c.Link(redis.Config{
+		Database:          5,
+		Prefix:            prefix,
+		SchemaKeys:        true,
+		TTL:               time.Minute,
+		SlidingExpiration: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	db := c.DB().(*goredis.Client)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		user := &pb.User{Id: rand.String(10)}
+		in := &hippo.Aggregate{
+			State:   user,
+			Version: 1,
+		}
+		if err := c.Set(ctx, user.GetId(), in); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, user.GetId())
+	}
+
+	// Ensure key includes prefix and schema, and expires.
+	key := prefix + ":agg:*user.User:" + ids[0]
+	if ttl, err := db.PTTL(key).Result(); err != nil {
+		t.Fatal(err)
+	} else if ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("unexpected ttl: %v", ttl)
+	}
+
+	// Get aggregate from cache.
+	out := &hippo.Aggregate{
+		State: &pb.User{},
+	}
+	if err := c.Get(ctx, ids[0], out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ids[0], out.State.(*pb.User).GetId())
+
+	// Delete one aggregate.
+	if err := c.Delete(ctx, ids[0], &pb.User{}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hippo.ErrKeyDoesNotExist, c.Get(ctx, ids[0], &hippo.Aggregate{State: &pb.User{}}))
+	assert.Equal(t, nil, c.Get(ctx, ids[1], &hippo.Aggregate{State: &pb.User{}}))
+
+	// Keys of other schemas and the checkpoints must survive a flush.
+	other := prefix + ":agg:*admin_user.User:" + ids[1]
+	if err := db.Set(other, "1", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	cp := c.CheckpointService()
+	if err := cp.Set(ctx, "projection", hippo.Position{Time: time.Unix(0, 1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flush the whole namespace.
+	if err := c.Flush(ctx, &pb.User{}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hippo.ErrKeyDoesNotExist, c.Get(ctx, ids[1], &hippo.Aggregate{State: &pb.User{}}))
+	assert.Equal(t, hippo.ErrKeyDoesNotExist, c.Get(ctx, ids[2], &hippo.Aggregate{State: &pb.User{}}))
+	assert.Equal(t, int64(1), db.Exists(other).Val())
+
+	// Flush all the aggregates under the prefix.
+	if err := c.Flush(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(0), db.Exists(other).Val())
+	pos, err := cp.Get(ctx, "projection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), pos.Time.UnixNano())
+}
+
+// Ensure aggregates cached in text format are still readable.
+func TestCacheService_GetText(t *testing.T) {
+	c := MustLinkCache()
+	defer c.Close()
+
+	user := &pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+
+	// Cache aggregate the way it was cached before the format was recorded.
+	db := c.DB().(*goredis.Client)
+	if err := db.HMSet(user.GetId(), map[string]interface{}{
+		"version": "3",
+		"schema":  "*user.User",
+		"state":   proto.CompactTextString(user),
+	}).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &hippo.Aggregate{
+		State: &pb.User{},
+	}
+	if err := c.Get(context.Background(), user.GetId(), out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(3), out.Version)
+	assert.Equal(t, true, proto.Equal(user, out.State.(*pb.User)))
+}
+
+// Ensure a schema mismatch is reported instead of decoding the state.
+func TestCacheService_GetInvalidSchema(t *testing.T) {
+	c := MustLinkCache()
+	defer c.Close()
+
+	user := &pb.User{
+		Id: rand.String(10),
+	}
+	in := &hippo.Aggregate{
+		State:   user,
+		Version: 1,
+	}
+
+	ctx := context.Background()
+	if err := c.Set(ctx, user.GetId(), in); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &hippo.Aggregate{
+		State: &empty.Empty{},
+	}
+	assert.Equal(t, hippo.ErrInvalidSchema, c.Get(ctx, user.GetId(), out))
+}
@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/go-redis/redis"
+)
+
+var _ hippo.CheckpointService = &CheckpointService{}
+
+// CheckpointService holds durable subscription checkpoints in Redis.
+type CheckpointService struct {
+	cache *CacheService
+}
+
+// key returns the Redis key of the subscription checkpoint.
+func (s *CheckpointService) key(name string) string {
+	if s.cache.prefix != "" {
+		return s.cache.prefix + ":checkpoint:" + name
+	}
+	return "checkpoint:" + name
+}
+
+// checkpoint represents the JSON encoding of a position.
+type checkpoint struct {
+	Time int64    `json:"time"`
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Get returns the checkpoint of the subscription, zero position if it does not exist.
+func (s *CheckpointService) Get(ctx context.Context, name string) (hippo.Position, error) {
+	cmd := s.cache.db.Get(s.key(name))
+	if cmd.Err() == redis.Nil {
+		return hippo.Position{}, nil
+	} else if cmd.Err() != nil {
+		return hippo.Position{}, cmd.Err()
+	}
+	// Checkpoints saved as nanoseconds since epoch only hold the time.
+	if n, err := strconv.ParseInt(cmd.Val(), 10, 64); err == nil {
+		return hippo.Position{Time: time.Unix(0, n).UTC()}, nil
+	}
+	var cp checkpoint
+	if err := json.Unmarshal([]byte(cmd.Val()), &cp); err != nil {
+		return hippo.Position{}, err
+	}
+	return hippo.Position{Time: time.Unix(0, cp.Time).UTC(), Keys: cp.Keys}, nil
+}
+
+// Set stores the checkpoint of the subscription as JSON, the time as nanoseconds since epoch.
+func (s *CheckpointService) Set(ctx context.Context, name string, position hippo.Position) error {
+	v, err := json.Marshal(&checkpoint{Time: position.Time.UnixNano(), Keys: position.Keys})
+	if err != nil {
+		return err
+	}
+	if cmd := s.cache.db.Set(s.key(name), v, 0); cmd.Err() != nil {
+		return cmd.Err()
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+)
+
+func TestCheckpointService_SetGet(t *testing.T) {
+	c := MustLinkCache()
+	defer c.Close()
+
+	ctx := context.Background()
+	name := rand.String(10)
+
+	// Get checkpoint not yet stored.
+	if pos, err := c.CheckpointService().Get(ctx, name); err != nil {
+		t.Fatal(err)
+	} else if !pos.IsZero() {
+		t.Fatalf("unexpected checkpoint: %v", pos)
+	}
+
+	// Set checkpoint.
+	now := time.Now().UTC()
+	if err := c.CheckpointService().Set(ctx, name, hippo.Position{Time: now, Keys: []string{"123ABC:1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Get checkpoint.
+	pos, err := c.CheckpointService().Get(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, true, now.Equal(pos.Time))
+	assert.Equal(t, []string{"123ABC:1"}, pos.Keys)
+}
@@ -0,0 +1,294 @@
+package redis
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/go-redis/redis"
+)
+
+// ConsumerConfig represents a configuration to consume events from Redis streams
+type ConsumerConfig struct {
+	// Group is the name of the consumer group, required.
+	Group string
+
+	// Name of the consumer within the group, required.
+	Name string
+
+	// Topics maps the event topics to the actions to run. When the publisher
+	// adds events to a stream per topic only exact topics are consumed.
+	Topics hippo.ActionTopics
+
+	// BatchSize maximum number of messages read at once, defaults to 10.
+	BatchSize int64
+
+	// Block maximum time to wait for new messages, defaults to 1s.
+	Block time.Duration
+
+	// MinIdle time after which a message delivered but not acknowledged
+	// is claimed for redelivery, defaults to 30s.
+	MinIdle time.Duration
+
+	// MaxDeliveries number of times a message is delivered before the failed
+	// actions are dead-lettered, defaults to 5.
+	MaxDeliveries int64
+
+	// DeadLetter receives the events whose actions failed on the last delivery, optional.
+	DeadLetter hippo.DeadLetterService
+
+	// RetryInterval delay before reading again after a Redis error, doubled after
+	// each consecutive error up to 30s, defaults to 100ms.
+	RetryInterval time.Duration
+}
+
+// consumer reads events from Redis streams as part of a consumer group.
+type consumer struct {
+	db      *redis.Client
+	conf    ConsumerConfig
+	streams []string
+}
+
+// Consume reads the events published to the Redis streams as part of a consumer
+// group and runs the respective actions until the context is done. A message is
+// acknowledged once all its actions succeed, otherwise it stays pending and is
+// redelivered to a consumer of the group after MinIdle. The actions that succeeded
+// are recorded, so that only the failed actions run again on redelivery. Actions
+// must still be idempotent, e.g. an action may succeed before it is recorded.
+// Redis errors are retried with backoff until the context is done.
+func (s *CacheService) Consume(ctx context.Context, conf ConsumerConfig) error {
+	if conf.Group == "" || conf.Name == "" {
+		return hippo.ErrConsumerGroupRequired
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 10
+	}
+	if conf.Block <= 0 {
+		conf.Block = time.Second
+	}
+	if conf.MinIdle <= 0 {
+		conf.MinIdle = 30 * time.Second
+	}
+	if conf.MaxDeliveries <= 0 {
+		conf.MaxDeliveries = 5
+	}
+	if conf.RetryInterval <= 0 {
+		conf.RetryInterval = 100 * time.Millisecond
+	}
+
+	c := &consumer{
+		db:   s.db,
+		conf: conf,
+	}
+
+	// Streams to read from.
+	if !s.publisher.perTopic {
+		c.streams = []string{s.publisher.stream}
+	} else {
+		for t := range conf.Topics {
+			if strings.ContainsAny(string(t), `*?[\`) {
+				log.Printf("redis: topic pattern %s can not be consumed from a stream per topic", t)
+				continue
+			}
+			c.streams = append(c.streams, s.publisher.Stream(string(t)))
+		}
+	}
+
+	if err := c.createGroups(); err != nil {
+		return err
+	}
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		err := c.claim(ctx)
+		if err == nil {
+			err = c.read(ctx)
+		}
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		// Retry with backoff, creating the groups again if the streams were deleted.
+		failures++
+		d := c.conf.RetryInterval << uint(failures-1)
+		if d > 30*time.Second || d <= 0 {
+			d = 30 * time.Second
+		}
+		log.Printf("redis: consumer %s of group %s failed, retrying in %v > error %v", conf.Name, conf.Group, d, err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(d):
+		}
+		if strings.HasPrefix(err.Error(), "NOGROUP") {
+			if err := c.createGroups(); err != nil {
+				log.Printf("redis: consumer group %s not created > error %v", conf.Group, err)
+			}
+		}
+	}
+}
+
+// createGroups creates the consumer group of the streams, starting from the
+// beginning of the streams.
+func (c *consumer) createGroups() error {
+	for _, stream := range c.streams {
+		err := c.db.XGroupCreateMkStream(stream, c.conf.Group, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return err
+		}
+	}
+	return nil
+}
+
+// read reads new messages delivered to the consumer.
+func (c *consumer) read(ctx context.Context) error {
+	streams := make([]string, 0, 2*len(c.streams))
+	streams = append(streams, c.streams...)
+	for range c.streams {
+		streams = append(streams, ">")
+	}
+	res, err := c.db.XReadGroup(&redis.XReadGroupArgs{
+		Group:    c.conf.Group,
+		Consumer: c.conf.Name,
+		Streams:  streams,
+		Count:    c.conf.BatchSize,
+		Block:    c.conf.Block,
+	}).Result()
+	if err == redis.Nil {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, stream := range res {
+		for _, msg := range stream.Messages {
+			if err := c.process(ctx, stream.Stream, msg, c.conf.MaxDeliveries <= 1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// claim claims the messages pending for longer than MinIdle and processes them again.
+func (c *consumer) claim(ctx context.Context) error {
+	for _, stream := range c.streams {
+		pending, err := c.db.XPendingExt(&redis.XPendingExtArgs{
+			Stream: stream,
+			Group:  c.conf.Group,
+			Start:  "-",
+			End:    "+",
+			Count:  c.conf.BatchSize,
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		var ids []string
+		deliveries := make(map[string]int64)
+		for _, p := range pending {
+			if p.Idle >= c.conf.MinIdle {
+				ids = append(ids, p.Id)
+				deliveries[p.Id] = p.RetryCount + 1
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		msgs, err := c.db.XClaim(&redis.XClaimArgs{
+			Stream:   stream,
+			Group:    c.conf.Group,
+			Consumer: c.conf.Name,
+			MinIdle:  c.conf.MinIdle,
+			Messages: ids,
+		}).Result()
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			if err := c.process(ctx, stream, msg, deliveries[msg.ID] >= c.conf.MaxDeliveries); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// process runs the actions of the event in the message not yet completed and
+// acknowledges it if all of them succeed. On the last delivery the failed actions
+// are dead-lettered and the message is acknowledged anyway. It returns an error
+// if the completed actions can not be read, the message is then redelivered.
+func (c *consumer) process(ctx context.Context, stream string, msg redis.XMessage, last bool) error {
+	e, err := Decode(msg.Values)
+	if err != nil {
+		log.Printf("redis: message %s from %s can not be decoded > error %v", msg.ID, stream, err)
+		c.ack(stream, msg.ID)
+		return nil
+	}
+
+	// Actions are identified by their index in the actions of the topic.
+	key := c.completed(stream, msg.ID)
+	done, err := c.db.SMembersMap(key).Result()
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for i, a := range c.conf.Topics.Actions(e.GetTopic()) {
+		if _, ok := done[strconv.Itoa(i)]; ok {
+			continue
+		}
+		start := time.Now()
+		if err := a(ctx, e); err != nil {
+			log.Printf("redis: action %v failed for event %v with aggregate %s version %d - duration: %v > error %v", i, e.Topic, e.AggregateID, e.Version, time.Now().Sub(start), err)
+			failed = true
+			if last && c.conf.DeadLetter != nil {
+				d := &hippo.DeadLetter{
+					Event:    e,
+					Action:   a,
+					Attempts: int(c.conf.MaxDeliveries),
+					Err:      err,
+				}
+				if err := c.conf.DeadLetter.Put(ctx, d); err != nil {
+					log.Printf("redis: event %s with aggregate %s version %d could not be dead-lettered > error %v", e.Topic, e.AggregateID, e.Version, err)
+				}
+			}
+			continue
+		}
+		if err := c.db.SAdd(key, i).Err(); err != nil {
+			log.Printf("redis: action %v completion for message %s from %s not recorded > error %v", i, msg.ID, stream, err)
+		}
+		log.Printf("redis: event %s with aggregate %s version %d action %v finished - duration: %v", e.Topic, e.AggregateID, e.Version, i, time.Now().Sub(start))
+	}
+
+	if failed && !last {
+		return nil
+	}
+	c.ack(stream, msg.ID)
+	return nil
+}
+
+// completed returns the key of the set of the actions completed for the message by the group.
+func (c *consumer) completed(stream, id string) string {
+	return stream + ":" + c.conf.Group + ":completed:" + id
+}
+
+// ack acknowledges the message, removing it from the pending entries of the
+// group, and removes the actions completed for it.
+func (c *consumer) ack(stream, id string) {
+	pipe := c.db.TxPipeline()
+	pipe.XAck(stream, c.conf.Group, id)
+	pipe.Del(c.completed(stream, id))
+	if _, err := pipe.Exec(); err != nil {
+		log.Printf("redis: message %s from %s not acknowledged > error %v", id, stream, err)
+	}
+}
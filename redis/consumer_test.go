@@ -0,0 +1,109 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/redis"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	goredis "github.com/go-redis/redis"
+	"github.com/paulormart/assert"
+)
+
+// Ensure events are consumed, only failed actions run again on redelivery and
+// messages acknowledged.
+func TestCacheService_Consume(t *testing.T) {
+	c := NewCache()
+	stream := rand.StringPrefix(10, "hippo:test:")
+	if err := c.Link(redis.Config{
+		Database:       5,
+		Stream:         stream,
+		StreamPerTopic: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+	ev1 := hippo.NewEventProto("user_created", user.GetId(), &user)
+	ev1.Version = 1
+	ev2 := hippo.NewEventProto("user_updated", user.GetId(), &user)
+	ev2.Version = 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, e := range []*hippo.Event{ev1, ev2} {
+		if err := c.Publisher().Publish(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mu := sync.Mutex{}
+	deliveries := make(map[string]int)
+	wg := &sync.WaitGroup{}
+	wg.Add(4)
+	a := func(ctx context.Context, e *hippo.Event) error {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries[e.Topic]++
+		// Fail the first delivery of user_updated.
+		if e.Topic == "user_updated" && deliveries[e.Topic] == 1 {
+			return errors.New("action failed")
+		}
+		return nil
+	}
+	b := func(ctx context.Context, e *hippo.Event) error {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries["b:"+e.Topic]++
+		return nil
+	}
+
+	go c.Consume(ctx, redis.ConsumerConfig{
+		Group:   "projection",
+		Name:    "consumer-1",
+		Topics:  hippo.ActionTopics{"user_created": []hippo.ActionFn{a}, "user_updated": []hippo.ActionFn{b, a}},
+		Block:   10 * time.Millisecond,
+		MinIdle: 10 * time.Millisecond,
+	})
+	wg.Wait()
+	cancel()
+
+	assert.Equal(t, 1, deliveries["user_created"])
+	assert.Equal(t, 2, deliveries["user_updated"])
+	assert.Equal(t, 1, deliveries["b:user_updated"])
+
+	// Ensure no messages are left pending.
+	db := c.DB().(*goredis.Client)
+	for _, topic := range []string{"user_created", "user_updated"} {
+		var n int64 = -1
+		for i := 0; i < 100 && n != 0; i++ {
+			res, err := db.XPending(stream+":"+topic, "projection").Result()
+			if err != nil {
+				t.Fatal(err)
+			}
+			n = res.Count
+			time.Sleep(time.Millisecond)
+		}
+		assert.Equal(t, int64(0), n)
+	}
+
+	// Ensure the completed actions are removed once acknowledged.
+	keys, err := db.Keys(stream + ":*:projection:completed:*").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(keys))
+}
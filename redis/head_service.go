@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aukbit/hippo"
+	"github.com/go-redis/redis"
+)
+
+var _ hippo.HeadService = &HeadService{}
+
+// HeadService holds the heads of the signature chains of the aggregates in Redis.
+type HeadService struct {
+	cache *CacheService
+}
+
+// key returns the Redis key of the head of the aggregate.
+func (s *HeadService) key(aggregateID string) string {
+	if s.cache.prefix != "" {
+		return s.cache.prefix + ":head:" + aggregateID
+	}
+	return "head:" + aggregateID
+}
+
+// setHeadScript writes the head hash only if the incoming version is greater
+// than the version stored, so that a slower writer never moves the head back.
+var setHeadScript = redis.NewScript(`
+local v = redis.call("HGET", KEYS[1], "version")
+if v and tonumber(v) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("HMSET", KEYS[1], "version", ARGV[1], "signature", ARGV[2])
+return 1
+`)
+
+// GetHead returns the head of the aggregate, the zero head if it does not exist.
+func (s *HeadService) GetHead(ctx context.Context, aggregateID string) (hippo.Head, error) {
+	cmd := s.cache.db.HGetAll(s.key(aggregateID))
+	if cmd.Err() != nil {
+		return hippo.Head{}, cmd.Err()
+	}
+	if len(cmd.Val()) == 0 {
+		return hippo.Head{}, nil
+	}
+	v, err := strconv.ParseInt(cmd.Val()["version"], 10, 64)
+	if err != nil {
+		return hippo.Head{}, err
+	}
+	return hippo.Head{Version: v, Signature: cmd.Val()["signature"]}, nil
+}
+
+// SetHead stores the head of the aggregate unless a newer head is already stored.
+func (s *HeadService) SetHead(ctx context.Context, aggregateID string, head hippo.Head) error {
+	version := strconv.FormatInt(head.Version, 10)
+	return setHeadScript.Run(s.cache.db, []string{s.key(aggregateID)}, version, head.Signature).Err()
+}
@@ -0,0 +1,38 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+)
+
+// Ensure heads are stored and never moved back.
+func TestHeadService_SetGet(t *testing.T) {
+	c := MustLinkCache()
+	defer c.Close()
+
+	ctx := context.Background()
+	id := rand.String(10)
+
+	// Get head not yet stored.
+	if head, err := c.HeadService().GetHead(ctx, id); err != nil {
+		t.Fatal(err)
+	} else {
+		assert.Equal(t, hippo.Head{}, head)
+	}
+
+	for _, head := range []hippo.Head{{Version: 2, Signature: "b"}, {Version: 1, Signature: "a"}} {
+		if err := c.HeadService().SetHead(ctx, id, head); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	head, err := c.HeadService().GetHead(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hippo.Head{Version: 2, Signature: "b"}, head)
+}
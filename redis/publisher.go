@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/internal"
+	"github.com/go-redis/redis"
+)
+
+var _ hippo.Publisher = &Publisher{}
+
+// Publisher publishes events to a Redis stream.
+type Publisher struct {
+	cache *CacheService
+
+	// stream events are added to
+	stream string
+
+	// perTopic adds events to a stream per topic
+	perTopic bool
+}
+
+// Stream returns the name of the stream the events of the topic are added to.
+func (p *Publisher) Stream(topic string) string {
+	if p.perTopic {
+		return p.stream + ":" + topic
+	}
+	return p.stream
+}
+
+// Publish encodes the event in the internal.Event protobuf envelope and adds
+// it to the stream.
+func (p *Publisher) Publish(ctx context.Context, e *hippo.Event) error {
+	start := time.Now()
+	data, err := internal.MarshalEvent(e)
+	if err != nil {
+		return err
+	}
+	stream := p.Stream(e.Topic)
+	cmd := p.cache.db.XAdd(&redis.XAddArgs{
+		Stream: stream,
+		Values: map[string]interface{}{
+			"topic":        e.Topic,
+			"aggregate_id": e.AggregateID,
+			"event":        data,
+		},
+	})
+	if cmd.Err() != nil {
+		return cmd.Err()
+	}
+	log.Printf("redis: event %s with aggregate %s version %d published to %s with id %s - duration: %v", e.Topic, e.AggregateID, e.Version, stream, cmd.Val(), time.Now().Sub(start))
+	return nil
+}
+
+// Decode decodes an event from the values of a Redis stream message.
+func Decode(values map[string]interface{}) (*hippo.Event, error) {
+	data, ok := values["event"].(string)
+	if !ok {
+		return nil, hippo.ErrEventFieldDoesNotExist
+	}
+	e := &hippo.Event{}
+	if err := internal.UnmarshalEvent([]byte(data), e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,46 @@
+package redis_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/redis"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	goredis "github.com/go-redis/redis"
+)
+
+// Ensure event is added to the stream and can be decoded.
+func TestPublisher_Publish(t *testing.T) {
+	c := MustLinkCache()
+	defer c.Close()
+
+	user := pb.User{
+		Id:    rand.String(10),
+		Name:  "test",
+		Email: "test@email.com",
+	}
+	event := hippo.NewEventProto("user_created", user.GetId(), &user)
+	event.Version = 1
+
+	if err := c.Publisher().Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read last message from the stream.
+	db := c.DB().(*goredis.Client)
+	msgs, err := db.XRevRangeN("hippo:events", "+", "-", 1).Result()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(msgs) != 1 {
+		t.Fatalf("unexpected number of messages: %d", len(msgs))
+	}
+
+	if other, err := redis.Decode(msgs[0].Values); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(event, other) {
+		t.Fatalf("unexpected copy: %#v != %#v", event, other)
+	}
+}
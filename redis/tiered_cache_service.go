@@ -0,0 +1,216 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/rand"
+)
+
+var _ hippo.CacheService = &TieredCacheService{}
+var _ hippo.CacheDeleter = &TieredCacheService{}
+
+// TieredCacheService layers a local cache over the Redis cache. Reads check the
+// local cache first and then Redis, writes go through both. Every write is announced
+// on a Redis channel, so that other replicas drop their stale local aggregates.
+type TieredCacheService struct {
+	local  hippo.CacheService
+	remote *CacheService
+
+	// id of this replica, so that its own invalidations are ignored
+	id string
+
+	// domain types by schema, to invalidate local aggregates by schema
+	mu    sync.Mutex
+	types map[string]interface{}
+
+	// reads from Redis in flight by schema and aggregate, so that an aggregate
+	// invalidated meanwhile by a newer version is not cached locally
+	fills map[string]*fill
+}
+
+// fill represents the reads from Redis in flight of an aggregate.
+type fill struct {
+	// number of reads in flight
+	n int
+
+	// highest version invalidated since the reads started, deleted
+	// aggregates invalidate all versions
+	version int64
+}
+
+// NewTieredCacheService creates a new TieredCacheService. The local cache, e.g.
+// memory.CacheService, must implement hippo.CacheDeleter to drop stale aggregates.
+func NewTieredCacheService(local hippo.CacheService, remote *CacheService) *TieredCacheService {
+	return &TieredCacheService{
+		local:  local,
+		remote: remote,
+		id:     rand.String(10),
+		types:  make(map[string]interface{}),
+		fills:  make(map[string]*fill),
+	}
+}
+
+// channel returns the Redis channel where invalidations are published.
+func (s *TieredCacheService) channel() string {
+	if s.remote.prefix != "" {
+		return s.remote.prefix + ":invalidate"
+	}
+	return "hippo:invalidate"
+}
+
+// register records the domain type of the schema.
+func (s *TieredCacheService) register(domainType interface{}) string {
+	schema := fmt.Sprintf("%T", domainType)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.types[schema]; !ok {
+		s.types[schema] = domainType
+	}
+	return schema
+}
+
+// Get returns the aggregate from the local cache, or from Redis if it is not
+// cached locally, in which case it is also cached locally unless a newer version
+// was invalidated meanwhile. The local cache never overwrites a newer version.
+func (s *TieredCacheService) Get(ctx context.Context, aggregateID string, out *hippo.Aggregate) error {
+	schema := s.register(out.State)
+	if err := s.local.Get(ctx, aggregateID, out); err == nil {
+		return nil
+	}
+
+	k := schema + " " + aggregateID
+	s.mu.Lock()
+	f, ok := s.fills[k]
+	if !ok {
+		f = &fill{version: -1}
+		s.fills[k] = f
+	}
+	f.n++
+	s.mu.Unlock()
+
+	err := s.remote.Get(ctx, aggregateID, out)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.n--; f.n == 0 {
+		delete(s.fills, k)
+	}
+	if err != nil {
+		return err
+	}
+	if f.version > out.Version {
+		return nil
+	}
+	if err := s.local.Set(ctx, aggregateID, out); err != nil {
+		log.Printf("redis: aggregate %s not cached locally > error %v", aggregateID, err)
+	}
+	return nil
+}
+
+// Set stores the aggregate in Redis and in the local cache, and announces
+// the write to the other replicas. Nothing is written if Redis already
+// holds a newer or equal version.
+func (s *TieredCacheService) Set(ctx context.Context, aggregateID string, in *hippo.Aggregate) error {
+	schema := s.register(in.State)
+	ok, err := s.remote.set(ctx, aggregateID, in)
+	if err != nil || !ok {
+		return err
+	}
+	if err := s.local.Set(ctx, aggregateID, in); err != nil {
+		return err
+	}
+	return s.invalidate(schema, aggregateID, in.Version)
+}
+
+// Delete invalidates the aggregate in Redis and in the local caches of all replicas.
+func (s *TieredCacheService) Delete(ctx context.Context, aggregateID string, domainType interface{}) error {
+	schema := s.register(domainType)
+	if err := s.remote.Delete(ctx, aggregateID, domainType); err != nil {
+		return err
+	}
+	if err := s.deleteLocal(ctx, aggregateID, domainType); err != nil {
+		return err
+	}
+	return s.invalidate(schema, aggregateID, math.MaxInt64)
+}
+
+// invalidate publishes the invalidation of the aggregate version to the other replicas.
+func (s *TieredCacheService) invalidate(schema, aggregateID string, version int64) error {
+	msg := strings.Join([]string{s.id, schema, strconv.FormatInt(version, 10), aggregateID}, " ")
+	if cmd := s.remote.db.Publish(s.channel(), msg); cmd.Err() != nil {
+		return cmd.Err()
+	}
+	return nil
+}
+
+// Listen drops the local aggregates written or deleted by other replicas
+// until the context is done.
+func (s *TieredCacheService) Listen(ctx context.Context) error {
+	ps := s.remote.db.Subscribe(s.channel())
+	defer ps.Close()
+
+	// Wait for the subscription to be confirmed.
+	if _, err := ps.Receive(); err != nil {
+		return err
+	}
+
+	ch := ps.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			parts := strings.SplitN(m.Payload, " ", 4)
+			if len(parts) != 4 || parts[0] == s.id {
+				continue
+			}
+			version, err := strconv.ParseInt(parts[2], 10, 64)
+			if err != nil {
+				continue
+			}
+			s.drop(ctx, parts[1], parts[3], version)
+		}
+	}
+}
+
+// drop deletes the local aggregate of the schema, so that reads in flight
+// of older versions do not cache it locally.
+func (s *TieredCacheService) drop(ctx context.Context, schema, aggregateID string, version int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.fills[schema+" "+aggregateID]; ok && version > f.version {
+		f.version = version
+	}
+	domainType, ok := s.types[schema]
+	// Aggregates of unknown schemas were never cached locally.
+	if !ok {
+		return
+	}
+	if err := s.deleteLocal(ctx, aggregateID, domainType); err != nil {
+		log.Printf("redis: local aggregate %s not invalidated > error %v", aggregateID, err)
+	}
+}
+
+// deleteLocal invalidates the aggregate in the local cache.
+func (s *TieredCacheService) deleteLocal(ctx context.Context, aggregateID string, domainType interface{}) error {
+	d, ok := s.local.(hippo.CacheDeleter)
+	if !ok {
+		return hippo.ErrNotImplemented
+	}
+	return d.Delete(ctx, aggregateID, domainType)
+}
+
+// DB returns the Redis db connection
+func (s *TieredCacheService) DB() interface{} {
+	return s.remote.DB()
+}
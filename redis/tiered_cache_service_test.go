@@ -0,0 +1,77 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/memory"
+	"github.com/aukbit/hippo/redis"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+)
+
+// Ensure replicas drop stale local aggregates written by other replicas.
+func TestTieredCacheService_Invalidate(t *testing.T) {
+	c := MustLinkCache()
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two replicas sharing the same Redis.
+	local1 := memory.NewCacheService(memory.Config{})
+	r1 := redis.NewTieredCacheService(local1, c.CacheService)
+	local2 := memory.NewCacheService(memory.Config{})
+	r2 := redis.NewTieredCacheService(local2, c.CacheService)
+	go r1.Listen(ctx)
+	go r2.Listen(ctx)
+	time.Sleep(10 * time.Millisecond)
+
+	id := rand.String(10)
+
+	// Replica 1 writes and replica 2 reads it through Redis.
+	if err := r1.Set(ctx, id, &hippo.Aggregate{State: &pb.User{Id: id, Name: "v1"}, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	out := &hippo.Aggregate{State: &pb.User{}}
+	if err := r2.Get(ctx, id, out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "v1", out.State.(*pb.User).GetName())
+	assert.Equal(t, 1, local2.Stats().Entries)
+
+	// Replica 1 writes a new version, replica 2 drops its local aggregate.
+	if err := r1.Set(ctx, id, &hippo.Aggregate{State: &pb.User{Id: id, Name: "v2"}, Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100 && local2.Stats().Entries != 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 0, local2.Stats().Entries)
+
+	out = &hippo.Aggregate{State: &pb.User{}}
+	if err := r2.Get(ctx, id, out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "v2", out.State.(*pb.User).GetName())
+	assert.Equal(t, int64(2), out.Version)
+
+	// Replica 1 keeps its own local aggregate.
+	assert.Equal(t, 1, local1.Stats().Entries)
+
+	// A stale write is neither cached in Redis nor locally.
+	local3 := memory.NewCacheService(memory.Config{})
+	r3 := redis.NewTieredCacheService(local3, c.CacheService)
+	if err := r3.Set(ctx, id, &hippo.Aggregate{State: &pb.User{Id: id, Name: "v1"}, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, local3.Stats().Entries)
+	out = &hippo.Aggregate{State: &pb.User{}}
+	if err := r3.Get(ctx, id, out); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "v2", out.State.(*pb.User).GetName())
+}
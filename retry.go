@@ -0,0 +1,123 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy represents how a failed action is retried before giving up.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times an action runs, including
+	// the first attempt. Zero or one means the action is never retried.
+	MaxAttempts int
+	// InitialInterval (optional) delay before the first retry, defaults to 100ms.
+	InitialInterval time.Duration
+	// MaxInterval (optional) upper bound of the delay between retries, defaults to 30s.
+	MaxInterval time.Duration
+	// Multiplier (optional) factor by which the delay grows after each retry, defaults to 2.
+	Multiplier float64
+	// Jitter (optional) randomization factor between 0 and 1 applied to each delay,
+	// e.g. 0.2 turns a delay of 1s into a random delay between 0.8s and 1.2s.
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries an action up to 5 times with exponential backoff.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     5,
+	InitialInterval: 100 * time.Millisecond,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.2,
+}
+
+// backoff returns the delay to wait before the nth retry, starting at 1.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	initial := p.InitialInterval
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	max := p.MaxInterval
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(initial) * math.Pow(multiplier, float64(n-1))
+	if d > float64(max) {
+		d = float64(max)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		d = d - jitter*d + rand.Float64()*2*jitter*d
+	}
+	return time.Duration(d)
+}
+
+// run calls the action until it succeeds, the attempts are exhausted or
+// the context is done. It returns the number of attempts and the last error.
+// Actions wrapped with WithRetry are not retried again.
+func (p RetryPolicy) run(ctx context.Context, e *Event, fn ActionFn) (int, error) {
+	attempts := 0
+	for {
+		attempts++
+		err := fn(ctx, e)
+		if err == nil {
+			return attempts, nil
+		}
+		// action already retried with its own policy
+		if n, ok := retried(err); ok {
+			return n, err
+		}
+		if attempts >= p.MaxAttempts {
+			return attempts, err
+		}
+		d := p.backoff(attempts)
+		log.Printf("pubsub: attempt %d failed for event %s with aggregate %s version %d - retry in: %v > error %v", attempts, e.Topic, e.AggregateID, e.Version, d, err)
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return attempts, err
+		case <-t.C:
+		}
+	}
+}
+
+// retryError is returned by actions wrapped with WithRetry once the retry policy
+// is exhausted.
+type retryError struct {
+	attempts int
+	err      error
+}
+
+func (e *retryError) Error() string { return e.err.Error() }
+
+func (e *retryError) Unwrap() error { return e.err }
+
+// WithRetry returns an action that retries fn according to the given policy.
+// A policy set per action takes precedence over the policy of the subscription.
+func WithRetry(fn ActionFn, p RetryPolicy) ActionFn {
+	return func(ctx context.Context, e *Event) error {
+		n, err := p.run(ctx, e, fn)
+		if err != nil {
+			return &retryError{attempts: n, err: err}
+		}
+		return nil
+	}
+}
+
+// retried returns the number of attempts made by an action wrapped with WithRetry.
+func retried(err error) (int, bool) {
+	var re *retryError
+	if errors.As(err, &re) {
+		return re.attempts, true
+	}
+	return 0, false
+}
@@ -0,0 +1,63 @@
+package hippo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paulormart/assert"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{
+		InitialInterval: 10 * time.Millisecond,
+		MaxInterval:     50 * time.Millisecond,
+		Multiplier:      2,
+	}
+	assert.Equal(t, 10*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 40*time.Millisecond, p.backoff(3))
+	assert.Equal(t, 50*time.Millisecond, p.backoff(4))
+
+	// Ensure jitter keeps the delay within bounds.
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(2)
+		if d < 10*time.Millisecond || d > 30*time.Millisecond {
+			t.Fatalf("unexpected backoff with jitter: %v", d)
+		}
+	}
+}
+
+func TestRetry_WithRetry(t *testing.T) {
+	ev := NewEvent("user_created", "123ABC")
+	calls := 0
+	a := func(ctx context.Context, e *Event) error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	}
+	p := RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}
+	if err := WithRetry(a, p)(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, calls)
+
+	// Ensure the number of attempts is reported once the policy is exhausted.
+	calls = 0
+	p.MaxAttempts = 2
+	err := WithRetry(a, p)(context.Background(), ev)
+	n, ok := retried(err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 2, n)
+
+	// Ensure actions wrapped with WithRetry are not retried by another policy.
+	calls = 0
+	n, err = RetryPolicy{MaxAttempts: 5}.run(context.Background(), ev, WithRetry(a, p))
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "temporary failure", err.Error())
+}
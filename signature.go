@@ -0,0 +1,258 @@
+package hippo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"log"
+	"sort"
+	"sync"
+)
+
+// Signer computes the signatures chaining the events of an aggregate. The signature
+// of an event is computed over its canonical encoding and the signature of the
+// previous event, so that any altered, inserted or deleted event breaks the chain.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner creates a new Signer. Events are signed with HMAC-SHA256 using the
+// key, or with SHA-256 if the key is empty.
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+// hash returns the hash function of the signer.
+func (s *Signer) hash() hash.Hash {
+	if len(s.key) == 0 {
+		return sha256.New()
+	}
+	return hmac.New(sha256.New, s.key)
+}
+
+// Sign returns the signature of the event chained to the signature of the previous event.
+func (s *Signer) Sign(e *Event, previous string) string {
+	h := s.hash()
+	writeCanonical(h, e, previous)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify checks the signature chain of the events of an aggregate, ordered by
+// version from the first event. Removing the last events of the aggregate keeps
+// the chain valid, use VerifyHead to detect it.
+func (s *Signer) Verify(events []*Event) error {
+	var previous string
+	for i, e := range events {
+		if e.Version != int64(i+1) {
+			return &SignatureError{AggregateID: e.AggregateID, Version: e.Version, Err: ErrVersionNotContinuous}
+		}
+		if !hmac.Equal([]byte(e.Signature), []byte(s.Sign(e, previous))) {
+			return &SignatureError{AggregateID: e.AggregateID, Version: e.Version, Err: ErrInvalidSignature}
+		}
+		previous = e.Signature
+	}
+	return nil
+}
+
+// VerifyHead checks the signature chain of the events of an aggregate the same as
+// Verify, and that the chain holds the head, so that removing the last events of
+// the aggregate is detected too. A head behind the last event is valid, since
+// the head is stored after the event.
+func (s *Signer) VerifyHead(events []*Event, head Head) error {
+	if err := s.Verify(events); err != nil {
+		return err
+	}
+	if head.Version == 0 {
+		return nil
+	}
+	if head.Version > int64(len(events)) {
+		var id string
+		if len(events) > 0 {
+			id = events[0].AggregateID
+		}
+		return &SignatureError{AggregateID: id, Version: int64(len(events)) + 1, Err: ErrEventNotFound}
+	}
+	e := events[head.Version-1]
+	if !hmac.Equal([]byte(e.Signature), []byte(head.Signature)) {
+		return &SignatureError{AggregateID: e.AggregateID, Version: e.Version, Err: ErrInvalidSignature}
+	}
+	return nil
+}
+
+// Head represents the last event of the signature chain of an aggregate.
+type Head struct {
+	Version   int64
+	Signature string
+}
+
+// HeadService represents a service for storing the heads of the signature chains
+// apart from the event store, so that deleting the last events of an aggregate
+// from the event store is detected.
+type HeadService interface {
+	// GetHead returns the head of the aggregate, the zero head if it is not stored.
+	GetHead(ctx context.Context, aggregateID string) (Head, error)
+	// SetHead stores the head of the aggregate unless a newer head is already stored.
+	SetHead(ctx context.Context, aggregateID string, head Head) error
+}
+
+// Ensure Heads implements HeadService.
+var _ HeadService = &Heads{}
+
+// Heads is an in-memory HeadService.
+type Heads struct {
+	mu sync.Mutex
+	m  map[string]Head
+}
+
+// NewHeads creates a new Heads
+func NewHeads() *Heads {
+	return &Heads{
+		m: make(map[string]Head),
+	}
+}
+
+// GetHead returns the head of the aggregate.
+func (s *Heads) GetHead(ctx context.Context, aggregateID string) (Head, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m[aggregateID], nil
+}
+
+// SetHead stores the head of the aggregate unless a newer head is already stored.
+func (s *Heads) SetHead(ctx context.Context, aggregateID string, head Head) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if head.Version > s.m[aggregateID].Version {
+		s.m[aggregateID] = head
+	}
+	return nil
+}
+
+// SignatureError represents the first event of an aggregate that breaks the signature chain.
+type SignatureError struct {
+	AggregateID string
+	Version     int64
+	Err         error
+}
+
+// Error returns the error message.
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("aggregate %s version %d: %v", e.AggregateID, e.Version, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *SignatureError) Unwrap() error { return e.Err }
+
+// writeCanonical writes the canonical encoding of the event, all fields but the
+// signature in a fixed order and length prefixed, followed by the previous signature.
+func writeCanonical(w hash.Hash, e *Event, previous string) {
+	writeInt := func(n int64) {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(n))
+		w.Write(b[:])
+	}
+	writeBytes := func(b []byte) {
+		writeInt(int64(len(b)))
+		w.Write(b)
+	}
+
+	writeBytes([]byte(e.Topic))
+	writeBytes([]byte(e.AggregateID))
+	writeInt(e.Version)
+	writeBytes([]byte(e.Schema))
+	writeInt(int64(e.Format))
+	writeBytes(e.Data)
+	writeInt(int64(e.Priority))
+	writeBytes([]byte(e.OriginName))
+	writeBytes([]byte(e.OriginIP))
+
+	keys := make([]string, 0, len(e.Metadata))
+	for k := range e.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	writeInt(int64(len(keys)))
+	for _, k := range keys {
+		writeBytes([]byte(k))
+		writeBytes([]byte(e.Metadata[k]))
+	}
+
+	writeInt(e.CreateTime.UnixNano())
+	writeBytes([]byte(previous))
+}
+
+// RegisterSigner assigns a signer to the client, so that Dispatch signs every event.
+func (c *Client) RegisterSigner(s *Signer) {
+	c.signer = s
+}
+
+// RegisterHeadService assigns a head service to the client, so that Dispatch
+// stores the head of the signature chain of every aggregate and Verify detects
+// the last events deleted.
+func (c *Client) RegisterHeadService(h HeadService) {
+	c.heads = h
+}
+
+// setHead stores the signed event as the head of the aggregate. The event is
+// already persisted so a failure only leaves the head behind.
+func (c *Client) setHead(ctx context.Context, e *Event) {
+	if c.heads == nil || e.Signature == "" {
+		return
+	}
+	if err := c.heads.SetHead(ctx, e.AggregateID, Head{Version: e.Version, Signature: e.Signature}); err != nil {
+		log.Printf("signer: head of aggregate %s version %d not stored > error %v", e.AggregateID, e.Version, err)
+	}
+}
+
+// lastSignature returns the signature of the last event of the aggregate, loading
+// the event from the event store if the aggregate was fetched from cache.
+func (c *Client) lastSignature(ctx context.Context, agg *Aggregate) (string, error) {
+	if agg.Version == 0 || agg.signature != "" {
+		return agg.signature, nil
+	}
+	events, err := c.store.EventService().List(ctx, Params{ID: agg.id, FromVersion: agg.Version, ToVersion: agg.Version})
+	if err != nil {
+		return "", err
+	}
+	for _, e := range events {
+		if e.Version == agg.Version {
+			return e.Signature, nil
+		}
+	}
+	return "", ErrEventNotFound
+}
+
+// Verify checks the signature chain of all the events of the aggregate with the
+// registered signer. It returns a *SignatureError for the first altered, inserted
+// or deleted event. The last events deleted are only detected against the head
+// of the registered head service.
+func (c *Client) Verify(ctx context.Context, aggregateID string) error {
+	if c.signer == nil {
+		return ErrSignerNotConfigured
+	}
+	events, err := c.store.EventService().List(ctx, Params{ID: aggregateID})
+	if err != nil {
+		return err
+	}
+	if c.heads == nil {
+		if len(events) == 0 {
+			return ErrAggregateIDWithoutEvents
+		}
+		return c.signer.Verify(events)
+	}
+	head, err := c.heads.GetHead(ctx, aggregateID)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 && head.Version == 0 {
+		return ErrAggregateIDWithoutEvents
+	}
+	if len(events) == 0 {
+		return &SignatureError{AggregateID: aggregateID, Version: 1, Err: ErrEventNotFound}
+	}
+	return c.signer.VerifyHead(events, head)
+}
@@ -0,0 +1,97 @@
+package hippo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/mock"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/paulormart/assert"
+)
+
+// Ensure dispatched events are signed and the chain detects altered, inserted or deleted events.
+func TestClient_Verify(t *testing.T) {
+	var ss mock.StoreService
+	var es mock.EventService
+	var events []*hippo.Event
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		events = append(events, e)
+		return nil
+	}
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return int64(len(events)), nil
+	}
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		var out []*hippo.Event
+		for _, e := range events {
+			if p.ToVersion == 0 || (e.Version >= p.FromVersion && e.Version <= p.ToVersion) {
+				out = append(out, e)
+			}
+		}
+		return out, nil
+	}
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(&ss)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	clt.RegisterSigner(hippo.NewSigner([]byte("secret")))
+	clt.RegisterHeadService(hippo.NewHeads())
+	ctx := context.Background()
+
+	id := rand.String(10)
+	assert.Equal(t, hippo.ErrAggregateIDWithoutEvents, clt.Verify(ctx, id))
+
+	for _, name := range []string{"Obi-Wan", "Ben", "Kenobi"} {
+		user := pb.User{Id: id, Name: name}
+		if _, err := clt.Dispatch(ctx, hippo.NewEventProto("user_updated", id, &user), &user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal(t, 64, len(events[0].Signature))
+	assert.Equal(t, nil, clt.Verify(ctx, id))
+
+	// Signatures differ without the key.
+	assert.Equal(t, false, hippo.NewSigner(nil).Sign(events[0], "") == events[0].Signature)
+
+	// Altered event.
+	data := events[1].Data
+	events[1].Data = []byte("altered")
+	err := clt.Verify(ctx, id)
+	var serr *hippo.SignatureError
+	assert.Equal(t, true, errors.As(err, &serr))
+	assert.Equal(t, int64(2), serr.Version)
+	assert.Equal(t, true, errors.Is(err, hippo.ErrInvalidSignature))
+	events[1].Data = data
+
+	// Deleted event.
+	all := events
+	events = []*hippo.Event{all[0], all[2]}
+	assert.Equal(t, true, errors.Is(clt.Verify(ctx, id), hippo.ErrVersionNotContinuous))
+
+	// Last event deleted, detected against the head.
+	events = all[:2]
+	err = clt.Verify(ctx, id)
+	assert.Equal(t, true, errors.As(err, &serr))
+	assert.Equal(t, int64(3), serr.Version)
+	assert.Equal(t, true, errors.Is(err, hippo.ErrEventNotFound))
+	events = nil
+	assert.Equal(t, true, errors.Is(clt.Verify(ctx, id), hippo.ErrEventNotFound))
+
+	// Inserted event, with the version of a deleted one but not signed in the chain.
+	inserted := *all[1]
+	inserted.Topic = "user_deleted"
+	events = []*hippo.Event{all[0], &inserted, all[2]}
+	assert.Equal(t, true, errors.Is(clt.Verify(ctx, id), hippo.ErrInvalidSignature))
+}
@@ -0,0 +1,129 @@
+package hippo
+
+import (
+	"log"
+	"strconv"
+)
+
+// SchemaVersionKey is the metadata key of the schema version of the event data.
+// Events without it are at schema version 1.
+const SchemaVersionKey = "schema_version"
+
+// SchemaVersion returns the schema version of the event data, 1 if not set.
+func (e *Event) SchemaVersion() int {
+	v, err := strconv.Atoi(e.Metadata[SchemaVersionKey])
+	if err != nil || v < 1 {
+		return 1
+	}
+	return v
+}
+
+// SetSchemaVersion assigns the schema version of the event data.
+func (e *Event) SetSchemaVersion(version int) {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]string)
+	}
+	e.Metadata[SchemaVersionKey] = strconv.Itoa(version)
+}
+
+// UpcasterFn represents a function type to transform an event of an older schema
+// version into the next one, e.g. to migrate its data or rename its schema. If the
+// function keeps the schema and schema version, the schema version is incremented.
+type UpcasterFn func(e *Event) error
+
+// upcasterKey represents the schema, topic and schema version an upcaster applies to.
+type upcasterKey struct {
+	schema  string
+	topic   string
+	version int
+}
+
+// Upcasters is a registry of the upcaster functions that transform old events
+// into the current shape when the aggregates are loaded, so that the stored
+// events are never rewritten.
+type Upcasters struct {
+	m map[upcasterKey]UpcasterFn
+}
+
+// NewUpcasters returns a new, empty, Upcasters registry.
+func NewUpcasters() *Upcasters {
+	return &Upcasters{m: make(map[upcasterKey]UpcasterFn)}
+}
+
+// Register assigns the upcaster of the events with the schema, topic and schema
+// version. An empty topic applies to the events of every topic without an
+// upcaster of their own.
+func (u *Upcasters) Register(schema, topic string, version int, fn UpcasterFn) {
+	k := upcasterKey{schema: schema, topic: topic, version: version}
+	if _, ok := u.m[k]; ok {
+		log.Printf("duplicate upcaster registered: %s %s version %d", schema, topic, version)
+		return
+	}
+	u.m[k] = fn
+}
+
+// upcaster returns the upcaster of the event, if any.
+func (u *Upcasters) upcaster(e *Event) (UpcasterFn, bool) {
+	v := e.SchemaVersion()
+	if fn, ok := u.m[upcasterKey{schema: e.Schema, topic: e.Topic, version: v}]; ok {
+		return fn, true
+	}
+	fn, ok := u.m[upcasterKey{schema: e.Schema, version: v}]
+	return fn, ok
+}
+
+// Upcast returns the event transformed by the upcasters until none applies.
+// The event itself is not changed, a copy is returned if any upcaster applies.
+func (u *Upcasters) Upcast(e *Event) (*Event, error) {
+	if u == nil {
+		return e, nil
+	}
+	fn, ok := u.upcaster(e)
+	if !ok {
+		return e, nil
+	}
+
+	out := *e
+	out.Metadata = make(map[string]string, len(e.Metadata))
+	for k, v := range e.Metadata {
+		out.Metadata[k] = v
+	}
+	// Every upcaster applies once at most, otherwise they run in a cycle.
+	for n := 0; ok; fn, ok = u.upcaster(&out) {
+		if n++; n > len(u.m) {
+			return nil, ErrUpcasterCycle
+		}
+		schema, version := out.Schema, out.SchemaVersion()
+		if err := fn(&out); err != nil {
+			return nil, err
+		}
+		if out.Schema == schema && out.SchemaVersion() == version {
+			out.SetSchemaVersion(version + 1)
+		}
+	}
+	return &out, nil
+}
+
+// Version returns the current schema version of the events with the schema and
+// topic, the version after the last upcaster registered, or 0 if there is none.
+func (u *Upcasters) Version(schema, topic string) int {
+	if u == nil {
+		return 0
+	}
+	v := 0
+	for k := range u.m {
+		if k.schema == schema && (k.topic == topic || k.topic == "") && k.version >= v {
+			v = k.version + 1
+		}
+	}
+	return v
+}
+
+// RegisterUpcaster assigns an upcaster of the events with the schema, topic and
+// schema version to the client, see Upcasters.Register.
+func (c *Client) RegisterUpcaster(schema, topic string, version int, fn UpcasterFn) {
+	if c.upcasters == nil {
+		c.upcasters = NewUpcasters()
+	}
+	c.upcasters.Register(schema, topic, version, fn)
+}
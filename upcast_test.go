@@ -0,0 +1,110 @@
+package hippo_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aukbit/hippo"
+	"github.com/aukbit/hippo/mock"
+	pb "github.com/aukbit/hippo/test/proto"
+	"github.com/aukbit/rand"
+	"github.com/golang/protobuf/proto"
+	"github.com/paulormart/assert"
+)
+
+// Ensure old events are upcasted to the current schema when the aggregate is loaded.
+func TestClient_Upcast(t *testing.T) {
+	var ss mock.StoreService
+	var es mock.EventService
+	var events []*hippo.Event
+
+	// Mock StoreService.EventService() call.
+	ss.EventServiceFn = func() *mock.EventService {
+		return &es
+	}
+	es.CreateFn = func(ctx context.Context, e *hippo.Event) error {
+		events = append(events, e)
+		return nil
+	}
+	es.GetLastVersionFn = func(ctx context.Context, aggregateID string) (int64, error) {
+		return int64(len(events)), nil
+	}
+	es.ListFn = func(ctx context.Context, p hippo.Params) ([]*hippo.Event, error) {
+		return events, nil
+	}
+
+	// Domain Type Rules
+	rules := func(topic string, buffer, previous interface{}) (next interface{}) {
+		return buffer
+	}
+
+	clt := hippo.NewClient(&ss)
+	clt.RegisterDomainRules(rules, &pb.User{})
+	// The proto package was renamed, the data is unchanged.
+	clt.RegisterUpcaster("*userv1.User", "", 1, func(e *hippo.Event) error {
+		e.Schema = "*user.User"
+		return nil
+	})
+	// Names are upper case since schema version 2.
+	clt.RegisterUpcaster("*user.User", "user_created", 1, func(e *hippo.Event) error {
+		var user pb.User
+		if err := e.UnmarshalProto(&user); err != nil {
+			return err
+		}
+		user.Name = strings.ToUpper(user.Name)
+		return e.MarshalProto(&user)
+	})
+	ctx := context.Background()
+
+	// Event stored with the old schema.
+	id := rand.String(10)
+	old := hippo.NewEventProto("user_created", id, &pb.User{Id: id, Name: "Rey"})
+	old.Schema = "*userv1.User"
+	old.Version = 1
+	events = append(events, old)
+
+	agg, err := clt.Fetch(ctx, id, &pb.User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "REY", agg.State.(*pb.User).GetName())
+	// Stored event is not rewritten.
+	assert.Equal(t, "*userv1.User", old.Schema)
+	assert.Equal(t, 1, old.SchemaVersion())
+
+	// New events are at the current schema version.
+	user := pb.User{Id: id, Name: "KYLO"}
+	if _, err := clt.Dispatch(ctx, hippo.NewEventProto("user_created", id, &user), &user); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, events[1].SchemaVersion())
+	agg, err = clt.Fetch(ctx, id, &pb.User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, true, proto.Equal(&user, agg.State.(*pb.User)))
+}
+
+// Ensure upcasters applied in a cycle are detected.
+func TestUpcasters_Cycle(t *testing.T) {
+	u := hippo.NewUpcasters()
+	u.Register("*a.User", "", 1, func(e *hippo.Event) error {
+		e.Schema = "*b.User"
+		return nil
+	})
+	u.Register("*b.User", "", 1, func(e *hippo.Event) error {
+		e.Schema = "*a.User"
+		return nil
+	})
+	e := hippo.NewEvent("user_created", rand.String(10))
+	e.Schema = "*a.User"
+	_, err := u.Upcast(e)
+	assert.Equal(t, hippo.ErrUpcasterCycle, err)
+
+	// Events without upcasters are returned as is.
+	e.Schema = "*c.User"
+	out, err := u.Upcast(e)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, e, out)
+}